@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTokenTTL = time.Hour
+	// minJWTSecretLength is the size of the SHA-256 output, shorter HMAC keys weaken the signature
+	minJWTSecretLength = 32
+	// APIKeySubjectPrefix is prepended to the names of api keys, so they never clash with usernames
+	APIKeySubjectPrefix = "key:"
+	// AdminKeySubject is the principal of requests authenticated with the ADMIN_API_KEY
+	AdminKeySubject = APIKeySubjectPrefix + "admin"
+)
+
+// Authenticator resolves bearer tokens and api keys into principals
+type Authenticator struct {
+	tokens  *TokenIssuer
+	apiKeys []apiKey
+	// dummyHash is checked for unknown users, so the login takes as long as for known users
+	dummyHash string
+}
+
+type apiKey struct {
+	key       []byte
+	principal Principal
+}
+
+// Config holds the secrets of the authenticator. JWTSecret signs the tokens and must be shared by all replicas,
+// AdminAPIKey is an api key with the admin role and APIKeys holds further keys as lines of name:role:key.
+type Config struct {
+	JWTSecret   string        `yaml:"jwt_secret" env:"JWT_SECRET" secret:"true" required:"true"`
+	JWTTTL      time.Duration `yaml:"jwt_ttl" env:"JWT_TTL" flag:"jwt-ttl" usage:"lifetime of issued tokens"`
+	AdminAPIKey string        `yaml:"admin_api_key" env:"ADMIN_API_KEY" secret:"true"`
+	APIKeys     string        `yaml:"api_keys" env:"API_KEYS" secret:"true"`
+}
+
+func DefaultConfig() Config {
+	return Config{JWTTTL: defaultTokenTTL}
+}
+
+// Validate reports all invalid settings
+func (c Config) Validate() error {
+	var errs []error
+	secret := strings.TrimSpace(c.JWTSecret)
+	if c.JWTSecret != "" && len(secret) < minJWTSecretLength {
+		errs = append(errs, fmt.Errorf("JWT_SECRET must have at least %d characters", minJWTSecretLength))
+	}
+	if c.JWTTTL <= 0 {
+		errs = append(errs, errors.New("JWT_TTL must be a positive duration, i.e. 1h"))
+	}
+	_, err := parseAPIKeys(c.APIKeys)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// CreateAuthenticator creates the authenticator of the config, the config has to be validated
+func CreateAuthenticator(cfg Config) (*Authenticator, error) {
+	secret := strings.TrimSpace(cfg.JWTSecret)
+	if secret == "" {
+		return nil, errors.New("no JWT secret configured")
+	}
+	dummyHash, err := HashPassword(rand.Text())
+	if err != nil {
+		return nil, err
+	}
+	authenticator := &Authenticator{tokens: NewTokenIssuer([]byte(secret), cfg.JWTTTL), dummyHash: dummyHash}
+
+	adminKey := strings.TrimSpace(cfg.AdminAPIKey)
+	if adminKey == "" {
+		slog.Warn("No admin api key configured")
+	} else {
+		authenticator.apiKeys = append(authenticator.apiKeys, apiKey{
+			key:       []byte(adminKey),
+			principal: Principal{Subject: AdminKeySubject, Role: RoleAdmin},
+		})
+	}
+	keys, err := parseAPIKeys(cfg.APIKeys)
+	if err != nil {
+		return nil, err
+	}
+	authenticator.apiKeys = append(authenticator.apiKeys, keys...)
+	return authenticator, nil
+}
+
+// parseAPIKeys parses lines of name:role:key, empty lines and lines starting with # are skipped
+func parseAPIKeys(content string) ([]apiKey, error) {
+	var keys []apiKey
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		parts := strings.SplitN(text, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("API_KEYS line %d: expected name:role:key", line)
+		}
+		role := Role(parts[1])
+		if !role.IsValid() {
+			return nil, fmt.Errorf("API_KEYS line %d: unknown role '%s'", line, parts[1])
+		}
+		keys = append(keys, apiKey{key: []byte(parts[2]), principal: Principal{Subject: APIKeySubjectPrefix + parts[0], Role: role}})
+	}
+	return keys, scanner.Err()
+}
+
+// IssueToken creates a bearer token for the principal
+func (a *Authenticator) IssueToken(principal Principal) (string, time.Time, error) {
+	return a.tokens.Issue(principal)
+}
+
+// VerifyToken returns the principal of a valid bearer token
+func (a *Authenticator) VerifyToken(token string) (Principal, error) {
+	return a.tokens.Verify(token)
+}
+
+// LookupAPIKey returns the principal of the api key. All keys are compared to not leak timing information.
+func (a *Authenticator) LookupAPIKey(key string) (Principal, bool) {
+	var found *Principal
+	for i := range a.apiKeys {
+		if subtle.ConstantTimeCompare([]byte(key), a.apiKeys[i].key) == 1 {
+			found = &a.apiKeys[i].principal
+		}
+	}
+	if found == nil {
+		return Principal{}, false
+	}
+	return *found, true
+}
+
+// CheckPassword verifies the password against the stored hash. An empty hash, i.e. of an unknown user,
+// never matches but takes the same time.
+func (a *Authenticator) CheckPassword(hash string, password string) bool {
+	if hash == "" {
+		_, _ = CheckPassword(a.dummyHash, password)
+		return false
+	}
+	ok, err := CheckPassword(hash, password)
+	if err != nil {
+		slog.Error("Unable to check password", slog.String("error", err.Error()))
+		return false
+	}
+	return ok
+}
@@ -0,0 +1,48 @@
+package auth
+
+import "context"
+
+type Role string
+
+const (
+	RoleCustomer  Role = "customer"
+	RoleBartender Role = "bartender"
+	RoleAdmin     Role = "admin"
+)
+
+// roleRanks orders the roles, a role includes the permissions of all lower ranked roles
+var roleRanks = map[Role]int{
+	RoleCustomer:  1,
+	RoleBartender: 2,
+	RoleAdmin:     3,
+}
+
+func (r Role) IsValid() bool {
+	_, ok := roleRanks[r]
+	return ok
+}
+
+// Includes reports whether the role grants at least the permissions of the required role
+func (r Role) Includes(required Role) bool {
+	return r.IsValid() && roleRanks[r] >= roleRanks[required]
+}
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	// Subject identifies the caller, i.e. the username or the name of the api key
+	Subject string `json:"subject"`
+	Role    Role   `json:"role"`
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the principal
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal of the request, if it has been authenticated
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const tokenIssuer = "ordersystem"
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token has expired")
+)
+
+// tokenMethods are the only signing methods accepted, tokens are always signed with HMAC-SHA256
+var tokenMethods = []string{jwt.SigningMethodHS256.Alg()}
+
+type tokenClaims struct {
+	Role Role `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// TokenIssuer issues and verifies JWT bearer tokens signed with a shared secret
+type TokenIssuer struct {
+	secret []byte
+	ttl    time.Duration
+	parser *jwt.Parser
+}
+
+func NewTokenIssuer(secret []byte, ttl time.Duration) *TokenIssuer {
+	return &TokenIssuer{
+		secret: secret,
+		ttl:    ttl,
+		parser: jwt.NewParser(jwt.WithValidMethods(tokenMethods), jwt.WithExpirationRequired(), jwt.WithIssuer(tokenIssuer)),
+	}
+}
+
+// Issue creates a token for the principal, valid for the ttl of the issuer
+func (i *TokenIssuer) Issue(principal Principal) (token string, expiresAt time.Time, err error) {
+	now := time.Now()
+	expiresAt = now.Add(i.ttl)
+	token, err = jwt.NewWithClaims(jwt.SigningMethodHS256, tokenClaims{
+		Role: principal.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			Subject:   principal.Subject,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}).SignedString(i.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// Verify checks signature and expiry of the token and returns its principal
+func (i *TokenIssuer) Verify(token string) (Principal, error) {
+	var claims tokenClaims
+	_, err := i.parser.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return i.secret, nil
+	})
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return Principal{}, ErrTokenExpired
+	}
+	if err != nil || claims.Subject == "" || !claims.Role.IsValid() {
+		return Principal{}, ErrInvalidToken
+	}
+	return Principal{Subject: claims.Subject, Role: claims.Role}, nil
+}
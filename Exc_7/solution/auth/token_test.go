@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signedToken creates a token of arbitrary header and claims, signed with HMAC-SHA256 and the secret of the issuer
+func signedToken(t *testing.T, issuer *TokenIssuer, header string, claims any) string {
+	t.Helper()
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, issuer.secret)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerify(t *testing.T) {
+	issuer := NewTokenIssuer([]byte("a-secret-that-is-long-enough-for-tests"), time.Hour)
+	principal := Principal{Subject: "anna", Role: RoleBartender}
+	valid, _, err := issuer.Issue(principal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(valid, ".")
+	now := time.Now()
+	claims := func(modify func(c *tokenClaims)) tokenClaims {
+		c := tokenClaims{Role: RoleBartender, RegisteredClaims: jwt.RegisteredClaims{Issuer: tokenIssuer, Subject: "anna",
+			IssuedAt: jwt.NewNumericDate(now), ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour))}}
+		modify(&c)
+		return c
+	}
+	otherIssuer := NewTokenIssuer([]byte("another-secret-that-is-long-enough"), time.Hour)
+	fromOtherIssuer, _, err := otherIssuer.Issue(principal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiredIssuer := NewTokenIssuer(issuer.secret, -time.Second)
+	expired, _, err := expiredIssuer.Issue(principal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adminPayload, _ := json.Marshal(claims(func(c *tokenClaims) { c.Role = RoleAdmin }))
+	tokenHeader := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	noneHeader := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`))
+	hs512, err := jwt.NewWithClaims(jwt.SigningMethodHS512, claims(func(c *tokenClaims) {})).SignedString(issuer.secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"issued token", valid, nil},
+		{"signed claims", signedToken(t, issuer, tokenHeader, claims(func(c *tokenClaims) {})), nil},
+		{"expired", expired, ErrTokenExpired},
+		{"expires now", signedToken(t, issuer, tokenHeader, claims(func(c *tokenClaims) { c.ExpiresAt = jwt.NewNumericDate(now) })), ErrTokenExpired},
+		{"missing expiry", signedToken(t, issuer, tokenHeader, claims(func(c *tokenClaims) { c.ExpiresAt = nil })), ErrInvalidToken},
+		{"signed with another secret", fromOtherIssuer, ErrInvalidToken},
+		{"tampered role", parts[0] + "." + base64.RawURLEncoding.EncodeToString(adminPayload) + "." + parts[2], ErrInvalidToken},
+		{"tampered signature", parts[0] + "." + parts[1] + "." + strings.Repeat("A", len(parts[2])), ErrInvalidToken},
+		{"missing signature", parts[0] + "." + parts[1], ErrInvalidToken},
+		{"extra part", valid + ".x", ErrInvalidToken},
+		{"alg none", noneHeader + "." + parts[1] + ".", ErrInvalidToken},
+		{"alg none signed", signedToken(t, issuer, noneHeader, claims(func(c *tokenClaims) {})), ErrInvalidToken},
+		{"other hmac method", hs512, ErrInvalidToken},
+		{"other issuer claim", signedToken(t, issuer, tokenHeader, claims(func(c *tokenClaims) { c.Issuer = "someone" })), ErrInvalidToken},
+		{"missing subject", signedToken(t, issuer, tokenHeader, claims(func(c *tokenClaims) { c.Subject = "" })), ErrInvalidToken},
+		{"unknown role", signedToken(t, issuer, tokenHeader, claims(func(c *tokenClaims) { c.Role = "root" })), ErrInvalidToken},
+		{"empty", "", ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := issuer.Verify(tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got != principal {
+				t.Errorf("Verify() = %v, want %v", got, principal)
+			}
+			if err != nil && got != (Principal{}) {
+				t.Errorf("Verify() returned %v together with error %v", got, err)
+			}
+		})
+	}
+}
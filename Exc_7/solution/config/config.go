@@ -0,0 +1,125 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"ordersystem/auth"
+	"ordersystem/pricing"
+	"ordersystem/ratelimit"
+	"ordersystem/repository"
+	"ordersystem/serving"
+	"ordersystem/storage"
+	"reflect"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// FileEnvKey selects the config file if the -config flag is not given
+	FileEnvKey = "CONFIG_FILE"
+	redacted   = "[redacted]"
+)
+
+// Config is the configuration of the ordersystem. Every setting is loaded from, in increasing precedence:
+// the defaults, the YAML config file, env variables and command-line flags. Secrets have no flags,
+// instead they can be loaded from files like all secrets, i.e. POSTGRES_PASSWORD_FILE.
+type Config struct {
+	Server    ServerConfig          `yaml:"server"`
+	Database  repository.Config     `yaml:"database"`
+	S3        storage.S3Config      `yaml:"s3"`
+	Receipt   storage.ReceiptConfig `yaml:"receipt"`
+	Auth      auth.Config           `yaml:"auth"`
+	Pricing   pricing.Config        `yaml:"pricing"`
+	Serving   serving.Config        `yaml:"serving"`
+	RateLimit ratelimit.Config      `yaml:"rate_limit"`
+
+	// File is the config file that was loaded, if any
+	File string `yaml:"-"`
+	// PrintConfig requests to print the effective config instead of starting the server
+	PrintConfig bool `yaml:"-"`
+}
+
+// ServerConfig holds the settings of the http server
+type ServerConfig struct {
+	Addr        string   `yaml:"addr" env:"LISTEN_ADDR" flag:"addr" required:"true" usage:"address the server listens on"`
+	CORSOrigins []string `yaml:"cors_origins" env:"CORS_ORIGINS" flag:"cors-origins" usage:"comma separated origins allowed to call the API"`
+	// ShutdownTimeout bounds draining requests and background workers on SIGTERM, it has to be shorter than the
+	// stop grace period of the container
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" usage:"time to drain requests and workers on shutdown"`
+}
+
+// Validate reports all invalid settings
+func (c ServerConfig) Validate() error {
+	var errs []error
+	if len(c.CORSOrigins) == 0 {
+		errs = append(errs, errors.New("CORS_ORIGINS must list at least one origin"))
+	}
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("SHUTDOWN_TIMEOUT must be a positive duration, i.e. 20s"))
+	}
+	return errors.Join(errs...)
+}
+
+// Default returns the config used for all settings without file, env variable or flag
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Addr:            ":3000",
+			CORSOrigins:     []string{"http://localhost", "http://localhost:3000"},
+			ShutdownTimeout: 20 * time.Second,
+		},
+		Database:  repository.Config{Port: 5432},
+		S3:        storage.DefaultS3Config(),
+		Receipt:   storage.DefaultReceiptConfig(),
+		Auth:      auth.DefaultConfig(),
+		Pricing:   pricing.DefaultConfig(),
+		Serving:   serving.DefaultConfig(),
+		RateLimit: ratelimit.DefaultConfig(),
+	}
+}
+
+type validator interface {
+	Validate() error
+}
+
+// Validate checks that all required settings are set and lets every section validate its settings.
+// All problems are reported at once.
+func (c *Config) Validate() error {
+	var errs []error
+	for _, s := range settings(c) {
+		if s.required && s.value.IsZero() {
+			errs = append(errs, fmt.Errorf("%s is required", s.describe()))
+		}
+	}
+	sections := reflect.ValueOf(c).Elem()
+	for i := 0; i < sections.NumField(); i++ {
+		section, ok := sections.Field(i).Interface().(validator)
+		if !ok {
+			continue
+		}
+		err := section.Validate()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Print writes the config as YAML, secrets are redacted
+func Print(w io.Writer, cfg *Config) error {
+	printed := *cfg
+	for _, s := range settings(&printed) {
+		if s.secret && !s.value.IsZero() {
+			s.value.SetString(redacted)
+		}
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	err := encoder.Encode(printed)
+	if err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,266 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"ordersystem/secrets"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// setting is a single configurable field of a section
+type setting struct {
+	// path is the YAML path, i.e. database.host
+	path     string
+	env      string
+	flag     string
+	usage    string
+	secret   bool
+	required bool
+	value    reflect.Value
+}
+
+func (s setting) describe() string {
+	if s.env == "" {
+		return s.path
+	}
+	return fmt.Sprintf("%s (%s)", s.path, s.env)
+}
+
+// settings lists the fields of all sections of cfg, their values can be set
+func settings(cfg *Config) []setting {
+	var list []setting
+	sections := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < sections.NumField(); i++ {
+		field := sections.Type().Field(i)
+		name := yamlName(field)
+		if name == "-" || field.Type.Kind() != reflect.Struct {
+			continue
+		}
+		section := sections.Field(i)
+		for j := 0; j < section.NumField(); j++ {
+			field := section.Type().Field(j)
+			if !field.IsExported() {
+				continue
+			}
+			list = append(list, setting{
+				path:     name + "." + yamlName(field),
+				env:      field.Tag.Get("env"),
+				flag:     field.Tag.Get("flag"),
+				usage:    field.Tag.Get("usage"),
+				secret:   field.Tag.Get("secret") == "true",
+				required: field.Tag.Get("required") == "true",
+				value:    section.Field(j),
+			})
+		}
+	}
+	return list
+}
+
+func yamlName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+// flagValue keeps the raw value of a flag, it is applied after the file and env variables
+type flagValue struct {
+	raw    string
+	isBool bool
+}
+
+func (f *flagValue) String() string {
+	return f.raw
+}
+
+func (f *flagValue) Set(raw string) error {
+	f.raw = raw
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	return f.isBool
+}
+
+// Load loads the config from the config file, env variables and the command-line arguments and validates it.
+// The config file is given by the -config flag or CONFIG_FILE. All problems are reported in a single error,
+// flag.ErrHelp is returned if the usage was requested with -help.
+func Load(args []string) (*Config, error) {
+	cfg := Default()
+	list := settings(cfg)
+
+	flags := flag.NewFlagSet("ordersystem", flag.ContinueOnError)
+	flags.StringVar(&cfg.File, "config", os.Getenv(FileEnvKey), "YAML config file (env "+FileEnvKey+")")
+	flags.BoolVar(&cfg.PrintConfig, "print-config", false, "print the effective config with secrets redacted and exit")
+	values := map[string]*flagValue{}
+	for _, s := range list {
+		if s.flag == "" || s.secret {
+			continue
+		}
+		value := &flagValue{raw: formatValue(s.value), isBool: s.value.Kind() == reflect.Bool}
+		values[s.flag] = value
+		flags.Var(value, s.flag, fmt.Sprintf("%s (env %s)", s.usage, s.env))
+	}
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+
+	var errs []error
+	if cfg.File != "" {
+		err = loadFile(cfg, cfg.File)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, s := range list {
+		err = loadEnv(s)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	flags.Visit(func(f *flag.Flag) {
+		value, ok := values[f.Name]
+		if !ok {
+			return
+		}
+		for _, s := range list {
+			if s.flag == f.Name {
+				err = setValue(s.value, value.raw)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("flag -%s: %w", f.Name, err))
+				}
+			}
+		}
+	})
+	err = cfg.Validate()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return cfg, nil
+}
+
+// loadFile overrides the defaults with the settings of the YAML file, unknown keys are rejected
+func loadFile(cfg *Config, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	defer file.Close()
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	err = decoder.Decode(cfg)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// loadEnv sets the setting from its env variable, secrets can also be loaded from the file in the _FILE variable
+func loadEnv(s setting) error {
+	if s.env == "" {
+		return nil
+	}
+	if s.secret {
+		secret, ok, err := secrets.LookupSecret(s.env)
+		if err != nil {
+			return fmt.Errorf("%s: %w", s.describe(), err)
+		}
+		if ok {
+			s.value.SetString(strings.TrimSpace(secret))
+		}
+		return nil
+	}
+	raw, ok := os.LookupEnv(s.env)
+	if !ok {
+		return nil
+	}
+	err := setValue(s.value, raw)
+	if err != nil {
+		return fmt.Errorf("%s: %w", s.describe(), err)
+	}
+	return nil
+}
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// setValue parses raw into the value, lists are comma separated
+func setValue(value reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+	switch {
+	case value.Type() == durationType:
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("'%s' is not a duration, i.e. 10s", raw)
+		}
+		value.SetInt(int64(duration))
+	case value.Kind() == reflect.String:
+		value.SetString(raw)
+	case value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("'%s' is not true or false", raw)
+		}
+		value.SetBool(b)
+	case value.CanInt():
+		i, err := strconv.ParseInt(raw, 10, value.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("'%s' is not a number", raw)
+		}
+		value.SetInt(i)
+	case value.CanUint():
+		u, err := strconv.ParseUint(raw, 10, value.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("'%s' is not a positive number", raw)
+		}
+		value.SetUint(u)
+	case value.CanFloat():
+		f, err := strconv.ParseFloat(raw, value.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("'%s' is not a number", raw)
+		}
+		value.SetFloat(f)
+	case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			item = strings.TrimSpace(item)
+			if item != "" {
+				items = append(items, item)
+			}
+		}
+		value.Set(reflect.ValueOf(items).Convert(value.Type()))
+	default:
+		panic(fmt.Sprintf("config: unsupported type %s", value.Type()))
+	}
+	return nil
+}
+
+// formatValue formats the value like setValue parses it, it is shown as default of the flags
+func formatValue(value reflect.Value) string {
+	if value.Type() == durationType {
+		return time.Duration(value.Int()).String()
+	}
+	if value.Kind() == reflect.Slice {
+		items := make([]string, value.Len())
+		for i := range items {
+			items[i] = value.Index(i).String()
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(value.Interface())
+}
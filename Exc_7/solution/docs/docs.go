@@ -15,34 +15,80 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/menu": {
-            "get": {
-                "description": "Returns the menu of all drinks",
+        "/api/auth/token": {
+            "post": {
+                "description": "Exchanges username and password for a bearer token",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Menu"
+                    "Auth"
+                ],
+                "parameters": [
+                    {
+                        "description": "Credentials",
+                        "name": "b",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/Credentials"
+                        }
+                    }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/Drink"
-                            }
+                            "$ref": "#/definitions/AccessToken"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
                     }
                 }
             }
         },
-        "/api/order": {
+        "/api/auth/users": {
             "post": {
-                "description": "Adds an order to the db",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates a user that can log in at /api/auth/token",
                 "consumes": [
                     "application/json"
                 ],
@@ -50,40 +96,79 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Order"
+                    "Auth"
                 ],
                 "parameters": [
                     {
-                        "description": "Order",
+                        "description": "User",
                         "name": "b",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/Order"
+                            "$ref": "#/definitions/NewUser"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK"
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/User"
+                        }
                     },
                     "400": {
-                        "description": "Bad Request"
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
                     }
                 }
             }
         },
-        "/api/order/all": {
+        "/api/menu": {
             "get": {
-                "description": "Returns all orders",
+                "description": "Returns the menu of all drinks, drinks running out of stock are flagged with low_stock.\neffective_price is the current price after pricing rules, price the list price.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Order"
+                    "Menu"
                 ],
                 "responses": {
                     "200": {
@@ -91,136 +176,3363 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/Order"
+                                "$ref": "#/definitions/Drink"
                             }
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
                     }
                 }
-            }
-        },
-        "/api/order/totalled": {
-            "get": {
-                "description": "Gets totalled orders",
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds a drink to the menu",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Order"
+                    "Menu"
+                ],
+                "parameters": [
+                    {
+                        "description": "Drink",
+                        "name": "b",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/DrinkRequest"
+                        }
+                    }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK"
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/Drink"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
                     }
                 }
             }
         },
-        "/api/receipt/{orderId}": {
-            "get": {
-                "description": "Get receipt for order",
+        "/api/menu/{drinkId}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Updates name, price, description, category, standard drinks and low stock threshold of a drink, the stock is changed by restocking",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
-                    "text/markdown"
+                    "application/json"
                 ],
                 "tags": [
-                    "Order"
+                    "Menu"
                 ],
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Order ID",
-                        "name": "orderId",
+                        "description": "Drink ID",
+                        "name": "drinkId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Drink",
+                        "name": "b",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/DrinkRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "file"
+                            "$ref": "#/definitions/Drink"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
                         }
                     },
                     "404": {
-                        "description": "Not Found"
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "DeletedAt": {
-            "type": "object",
-            "properties": {
-                "time": {
-                    "type": "string"
-                },
-                "valid": {
-                    "description": "Valid is true if Time is not NULL",
-                    "type": "boolean"
-                }
-            }
-        },
-        "Drink": {
-            "type": "object",
-            "properties": {
-                "created_at": {
-                    "type": "string"
-                },
-                "deletedAt": {
-                    "$ref": "#/definitions/DeletedAt"
-                },
-                "description": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "price": {
-                    "type": "number"
-                },
-                "updated_at": {
-                    "type": "string"
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Removes a drink from the menu, drinks that have been ordered before are only retired",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Menu"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Drink ID",
+                        "name": "drinkId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
                 }
             }
         },
-        "Order": {
-            "type": "object",
-            "properties": {
-                "amount": {
-                    "type": "integer"
-                },
+        "/api/menu/{drinkId}/restock": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds servings to the stock of a drink, untracked drinks start tracking their stock",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Menu"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Drink ID",
+                        "name": "drinkId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Restock",
+                        "name": "b",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/Restock"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Drink"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/order": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds an order with one or more drinks to the db.\nRetries with the same Idempotency-Key return the original response without placing the order again.\nThe order is recorded as created by the authenticated principal. With tab_id it is put on that open tab.\nOrders exceeding the stock of a drink are rejected with 409.\nUnit prices are taken from the menu after pricing rules, the applied rule is stored on each item.\nAn optional voucher_code is redeemed on one unit of an eligible drink, expired or used up vouchers are rejected with 409.\nOrders of customers exceeding the responsible serving limits are flagged or rejected with 403.\nInvalid fields and unknown drinks are answered with 422 listing every field error.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Order"
+                ],
+                "parameters": [
+                    {
+                        "description": "Order",
+                        "name": "b",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/NewOrder"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key of this order submission",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/order/all": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns one page of orders. The total number of matching orders is returned in the X-Total-Count header,\nthe next page is linked in the Link header.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Order"
+                ],
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "placed",
+                                "preparing",
+                                "served",
+                                "paid",
+                                "cancelled"
+                            ],
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Order status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only orders containing this drink",
+                        "name": "drink_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or after (RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created before (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum total quantity",
+                        "name": "min_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum total quantity",
+                        "name": "max_amount",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "-id",
+                            "created_at",
+                            "-created_at",
+                            "amount",
+                            "-amount"
+                        ],
+                        "type": "string",
+                        "description": "Sort field, prefix with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 100, max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor of the next page, only valid with the sort it was issued for",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/Order"
+                            }
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Link to the next page"
+                            },
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of matching orders"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/order/stream": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Live stream of order events as server-sent events. Each event carries the order after the change.\nEvent ids follow the order in which events are committed, reconnecting clients resume after the event\ngiven in the Last-Event-ID header.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Order"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Only events of orders containing this drink",
+                        "name": "drink_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Resume after this event id",
+                        "name": "last_event_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Resume after this event id",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stream of order.created and order.changed events",
+                        "schema": {
+                            "$ref": "#/definitions/Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/order/totalled": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Gets quantity, gross revenue and average unit price per drink",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Order"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Created at or after (RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created before (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/DrinkOrderTotal"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/order/{orderId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Cancels an order, the receipt is replaced by a void one in the background",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Order"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "orderId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Cancellation reason",
+                        "name": "b",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/OrderCancellation"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/order/{orderId}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Undoes the cancellation of an order (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Order"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "orderId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/order/{orderId}/status": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Moves an order to a new status (placed -\u003e preparing -\u003e served -\u003e paid, or cancelled)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Order"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "orderId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New status",
+                        "name": "b",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/OrderStatusUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/pricing/rules": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns all pricing rules",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Pricing"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/PricingRule"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds a pricing rule, i.e. a happy hour. Weekdays and daily window are in the timezone of the venue.\nWhen several rules match a drink the lowest price wins.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Pricing"
+                ],
+                "parameters": [
+                    {
+                        "description": "Pricing rule",
+                        "name": "b",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/PricingRuleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/PricingRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/pricing/rules/{ruleId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Removes a pricing rule, orders placed with it keep their price",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Pricing"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Pricing rule ID",
+                        "name": "ruleId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/receipt/preview": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Renders a receipt template against a sample order.\nThe template only applies to markdown receipts and the PDF receipts made from them, HTML receipts keep their built-in layout.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/markdown"
+                ],
+                "tags": [
+                    "Order"
+                ],
+                "parameters": [
+                    {
+                        "description": "Template",
+                        "name": "b",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ReceiptPreview"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/receipt/{orderId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get receipt for order, answers 202 while the receipt is being written.\nThe format is chosen by the Accept header, the format query param takes precedence.\nDepending on delivery the receipt is streamed (proxy), returned as presigned link (url)\nor the client is redirected to the presigned link (redirect). The default is configured by RECEIPT_DELIVERY.\nCustomers only get receipts of their own orders.\nOnly markdown receipts use the configurable receipt template. PDF receipts contain the rendered markdown as\nplain text without layout, HTML receipts use a fixed built-in layout and JSON receipts are the order itself.",
+                "produces": [
+                    "text/markdown",
+                    "text/html",
+                    "application/json",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "Order"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "orderId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "markdown",
+                            "html",
+                            "json",
+                            "pdf"
+                        ],
+                        "type": "string",
+                        "description": "Receipt format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "proxy",
+                            "url",
+                            "redirect"
+                        ],
+                        "type": "string",
+                        "description": "Receipt delivery",
+                        "name": "delivery",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted"
+                    },
+                    "302": {
+                        "description": "Found"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/serving/decisions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns the latest orders flagged or rejected by the responsible serving policy, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Serving"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only decisions on orders of this principal",
+                        "name": "customer",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "flag",
+                            "reject"
+                        ],
+                        "type": "string",
+                        "description": "Only flagged or rejected orders",
+                        "name": "action",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/ServingDecision"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tab": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Opens a tab, orders can be put on it until it is closed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tab"
+                ],
+                "parameters": [
+                    {
+                        "description": "Tab",
+                        "name": "b",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/TabOpening"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/Tab"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tab/{tabId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns the tab with its orders. The total of open tabs is the running total.\nCustomers only see their own tabs.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tab"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tab ID",
+                        "name": "tabId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Tab"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tab/{tabId}/bill": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the consolidated bill of a closed tab, answers 202 while the bill is being written",
+                "produces": [
+                    "text/markdown"
+                ],
+                "tags": [
+                    "Tab"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tab ID",
+                        "name": "tabId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tab/{tabId}/close": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Closes the tab: served orders are marked as paid and the bill is split evenly or by line items\namong the payers. The consolidated bill is written to S3 asynchronously.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tab"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tab ID",
+                        "name": "tabId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Bill split",
+                        "name": "b",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/TabClosing"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Tab"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tab/{tabId}/orders": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Puts an existing order on an open tab. Customers can only put their own orders on their own tabs.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tab"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tab ID",
+                        "name": "tabId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Order",
+                        "name": "b",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/TabAttachment"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Tab"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/voucher/batches": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Generates a batch of voucher codes. Codes can be single-use (max_redemptions 1) or multi-use,\nexpire at expires_at and optionally only apply to one drink. Without discount a voucher is good for a free drink.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Voucher"
+                ],
+                "parameters": [
+                    {
+                        "description": "Voucher batch",
+                        "name": "b",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/NewVoucherBatch"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/VoucherBatch"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/voucher/batches/{batchId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns a voucher batch with all its codes and how often they have been redeemed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Voucher"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Voucher batch ID",
+                        "name": "batchId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/VoucherBatch"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/voucher/usage": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Reports per batch how many codes have been redeemed and the discount granted on them.\nCancelled orders don't count.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Voucher"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/VoucherUsage"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Liveness probe, answers as long as the process serves requests",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Health"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Readiness probe, checks that postgres and S3 with the orders bucket are reachable.\nAnswers 503 if a check fails or the server is shutting down. The reasons of failed checks are only logged.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Health"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/Health"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "AccessToken": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "role": {
+                    "$ref": "#/definitions/Role"
+                },
+                "token_type": {
+                    "type": "string",
+                    "example": "Bearer"
+                }
+            }
+        },
+        "Code": {
+            "type": "string",
+            "enum": [
+                "invalid_body",
+                "invalid_parameter",
+                "validation_failed",
+                "body_too_large",
+                "unauthorized",
+                "forbidden",
+                "not_found",
+                "method_not_allowed",
+                "conflict",
+                "not_acceptable",
+                "rate_limited",
+                "internal_error",
+                "storage_unavailable",
+                "service_unavailable",
+                "idempotency_key_invalid",
+                "idempotency_key_reused",
+                "idempotency_in_progress",
+                "order_not_found",
+                "empty_order",
+                "mixed_currencies",
+                "amount_overflow",
+                "illegal_transition",
+                "order_cancelled",
+                "order_not_cancelled",
+                "receipt_failed",
+                "unknown_format",
+                "invalid_template",
+                "drink_not_found",
+                "unknown_drink",
+                "invalid_drink",
+                "drink_name_taken",
+                "insufficient_stock",
+                "invalid_restock",
+                "tab_not_found",
+                "unknown_tab",
+                "invalid_tab",
+                "tab_open",
+                "tab_closed",
+                "tab_unsettled",
+                "order_on_other_tab",
+                "invalid_split",
+                "invalid_user",
+                "username_taken",
+                "password_too_short",
+                "invalid_pricing_rule",
+                "pricing_rule_not_found",
+                "invalid_voucher_batch",
+                "voucher_batch_not_found",
+                "unknown_voucher",
+                "voucher_expired",
+                "voucher_used_up",
+                "voucher_not_applicable",
+                "serving_limit_exceeded"
+            ],
+            "x-enum-varnames": [
+                "CodeInvalidBody",
+                "CodeInvalidParameter",
+                "CodeValidationFailed",
+                "CodeBodyTooLarge",
+                "CodeUnauthorized",
+                "CodeForbidden",
+                "CodeNotFound",
+                "CodeMethodNotAllowed",
+                "CodeConflict",
+                "CodeNotAcceptable",
+                "CodeRateLimited",
+                "CodeInternal",
+                "CodeStorageUnavailable",
+                "CodeUnavailable",
+                "CodeIdempotencyKeyInvalid",
+                "CodeIdempotencyKeyReused",
+                "CodeIdempotencyInProgress",
+                "CodeOrderNotFound",
+                "CodeEmptyOrder",
+                "CodeMixedCurrencies",
+                "CodeAmountOverflow",
+                "CodeIllegalTransition",
+                "CodeOrderCancelled",
+                "CodeOrderNotCancelled",
+                "CodeReceiptFailed",
+                "CodeUnknownFormat",
+                "CodeInvalidTemplate",
+                "CodeDrinkNotFound",
+                "CodeUnknownDrink",
+                "CodeInvalidDrink",
+                "CodeDrinkNameTaken",
+                "CodeInsufficientStock",
+                "CodeInvalidRestock",
+                "CodeTabNotFound",
+                "CodeUnknownTab",
+                "CodeInvalidTab",
+                "CodeTabOpen",
+                "CodeTabClosed",
+                "CodeTabUnsettled",
+                "CodeOrderOnOtherTab",
+                "CodeInvalidSplit",
+                "CodeInvalidUser",
+                "CodeUsernameTaken",
+                "CodePasswordTooShort",
+                "CodeInvalidPricingRule",
+                "CodeRuleNotFound",
+                "CodeInvalidBatch",
+                "CodeBatchNotFound",
+                "CodeUnknownVoucher",
+                "CodeVoucherExpired",
+                "CodeVoucherUsedUp",
+                "CodeVoucherNotApplies",
+                "CodeServingLimit"
+            ]
+        },
+        "Credentials": {
+            "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "DeletedAt": {
+            "type": "object",
+            "properties": {
+                "time": {
+                    "type": "string"
+                },
+                "valid": {
+                    "description": "Valid is true if Time is not NULL",
+                    "type": "boolean"
+                }
+            }
+        },
+        "DiscountType": {
+            "type": "string",
+            "enum": [
+                "percent",
+                "fixed"
+            ],
+            "x-enum-varnames": [
+                "DiscountPercent",
+                "DiscountFixed"
+            ]
+        },
+        "Drink": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "description": "Category groups drinks for pricing rules, i.e. beer or wine",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/DeletedAt"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "effective_price": {
+                    "description": "EffectivePrice is the price after pricing rules at the time the menu is requested",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/Money"
+                        }
+                    ]
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "low_stock": {
+                    "type": "boolean"
+                },
+                "low_stock_threshold": {
+                    "description": "LowStockThreshold flags the drink as low on stock once its stock drops to the threshold",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "$ref": "#/definitions/Money"
+                },
+                "pricing_rule": {
+                    "description": "PricingRule is the name of the rule the effective price comes from",
+                    "type": "string"
+                },
+                "standard_drinks": {
+                    "description": "StandardDrinks is the alcohol of one serving in standard drink units, 0 for non-alcoholic drinks",
+                    "type": "number",
+                    "example": 2
+                },
+                "stock": {
+                    "description": "Stock is the number of servings left. Drinks without stock are not tracked and never sell out.",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "DrinkOrderTotal": {
+            "type": "object",
+            "properties": {
+                "average_unit_price": {
+                    "$ref": "#/definitions/Money"
+                },
+                "drink_id": {
+                    "type": "integer"
+                },
+                "gross_revenue": {
+                    "description": "GrossRevenue is based on the unit prices at the time the orders were placed",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/Money"
+                        }
+                    ]
+                },
+                "total_amount_ordered": {
+                    "type": "integer"
+                }
+            }
+        },
+        "DrinkRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "category": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "beer"
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "low_stock_threshold": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Beer"
+                },
+                "price": {
+                    "$ref": "#/definitions/MoneyRequest"
+                },
+                "standard_drinks": {
+                    "type": "number",
+                    "maximum": 20,
+                    "example": 2
+                },
+                "stock": {
+                    "description": "Stock is only taken when the drink is added, see Restock",
+                    "type": "integer"
+                }
+            }
+        },
+        "FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "items[0].quantity"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "must be at least 1"
+                },
+                "rule": {
+                    "type": "string",
+                    "example": "min"
+                }
+            }
+        },
+        "Health": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "description": "Checks holds the result of every dependency, ok or unavailable",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "Money": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string",
+                    "example": "1.40"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "EUR"
+                }
+            }
+        },
+        "MoneyRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string",
+                    "example": "1.40"
+                },
+                "currency": {
+                    "description": "Currency defaults to EUR",
+                    "type": "string",
+                    "example": "EUR"
+                }
+            }
+        },
+        "NewOrder": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "maxItems": 50,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/NewOrderItem"
+                    }
+                },
+                "tab_id": {
+                    "type": "integer"
+                },
+                "voucher_code": {
+                    "type": "string",
+                    "maxLength": 64
+                }
+            }
+        },
+        "NewOrderItem": {
+            "type": "object",
+            "required": [
+                "drink_id"
+            ],
+            "properties": {
+                "drink_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "quantity": {
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 1,
+                    "example": 2
+                }
+            }
+        },
+        "NewUser": {
+            "type": "object",
+            "required": [
+                "role",
+                "username"
+            ],
+            "properties": {
+                "password": {
+                    "description": "Password needs at least auth.MinPasswordLength characters",
+                    "type": "string",
+                    "maxLength": 1000,
+                    "minLength": 8
+                },
+                "role": {
+                    "enum": [
+                        "customer",
+                        "bartender",
+                        "admin"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/Role"
+                        }
+                    ]
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "NewVoucherBatch": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "count": {
+                    "description": "Count is the number of codes to generate",
+                    "type": "integer",
+                    "maximum": 1000,
+                    "minimum": 1,
+                    "example": 50
+                },
+                "discount": {
+                    "description": "Discount is only needed for fixed discounts",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/MoneyRequest"
+                        }
+                    ]
+                },
+                "discount_type": {
+                    "description": "DiscountType defaults to a free drink, i.e. 100 percent off",
+                    "enum": [
+                        "percent",
+                        "fixed"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/DiscountType"
+                        }
+                    ]
+                },
+                "drink_id": {
+                    "type": "integer"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "max_redemptions": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Brewery free beer"
+                },
+                "percent": {
+                    "type": "integer",
+                    "maximum": 100,
+                    "example": 100
+                },
+                "prefix": {
+                    "description": "Prefix is put in front of every code, i.e. the name of the sponsor",
+                    "type": "string",
+                    "maxLength": 12,
+                    "example": "BREW"
+                }
+            }
+        },
+        "Order": {
+            "type": "object",
+            "properties": {
+                "cancellation_reason": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "description": "CreatedBy is the subject of the principal that placed the order",
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/DeletedAt"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "items": {
+                    "description": "has many",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/OrderItem"
+                    }
+                },
+                "receipt_status": {
+                    "description": "ReceiptStatus tells whether the receipt in S3 is up-to-date with the order",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/ReceiptStatus"
+                        }
+                    ]
+                },
+                "serving_flagged": {
+                    "description": "ServingFlagged marks orders the responsible serving policy flagged for the bar staff",
+                    "type": "boolean"
+                },
+                "status": {
+                    "$ref": "#/definitions/OrderStatus"
+                },
+                "status_history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/OrderStatusChange"
+                    }
+                },
+                "tab_id": {
+                    "description": "Relationships\nforeign key, optional",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "voucher_code": {
+                    "description": "VoucherCode is the voucher redeemed on the order, optional",
+                    "type": "string"
+                }
+            }
+        },
+        "OrderCancellation": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 500
+                }
+            }
+        },
+        "OrderItem": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/DeletedAt"
+                },
+                "drink": {
+                    "$ref": "#/definitions/Drink"
+                },
+                "drink_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "list_price": {
+                    "$ref": "#/definitions/Money"
+                },
+                "order_id": {
+                    "description": "Relationships\nforeign keys",
+                    "type": "integer"
+                },
+                "pricing_rule": {
+                    "type": "string"
+                },
+                "pricing_rule_id": {
+                    "description": "PricingRuleID and PricingRuleName record the rule that discounted the item, the name is kept\nas it was when the order was placed",
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "standard_drinks": {
+                    "description": "StandardDrinks of one unit as they were when the order was placed, so editing the drink keeps past consumption",
+                    "type": "number"
+                },
+                "unit_price": {
+                    "description": "UnitPrice is the price charged, the list price of the menu after pricing rules when the order is placed",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/Money"
+                        }
+                    ]
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "voucher_id": {
+                    "description": "VoucherID is set on the single unit the voucher of the order was redeemed on",
+                    "type": "integer"
+                }
+            }
+        },
+        "OrderStatus": {
+            "type": "string",
+            "enum": [
+                "placed",
+                "preparing",
+                "served",
+                "paid",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "OrderStatusPlaced",
+                "OrderStatusPreparing",
+                "OrderStatusServed",
+                "OrderStatusPaid",
+                "OrderStatusCancelled"
+            ]
+        },
+        "OrderStatusChange": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/DeletedAt"
+                },
+                "from_status": {
+                    "$ref": "#/definitions/OrderStatus"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "order_id": {
+                    "description": "Relationships\nforeign key",
+                    "type": "integer"
+                },
+                "to_status": {
+                    "$ref": "#/definitions/OrderStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "OrderStatusUpdate": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "status": {
+                    "$ref": "#/definitions/OrderStatus"
+                }
+            }
+        },
+        "PricingRule": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string",
+                    "example": "beer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/DeletedAt"
+                },
+                "discount": {
+                    "$ref": "#/definitions/Money"
+                },
+                "discount_type": {
+                    "description": "DiscountType selects whether Percent or Discount is taken off the list price",
+                    "enum": [
+                        "percent",
+                        "fixed"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/DiscountType"
+                        }
+                    ]
+                },
+                "drink_id": {
+                    "description": "DrinkID and Category select the discounted drinks, empty selectors match every drink",
+                    "type": "integer"
+                },
+                "end_time": {
+                    "type": "string",
+                    "example": "19:00"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "percent": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "start_time": {
+                    "description": "StartTime and EndTime form a daily window, an end before the start wraps past midnight.\nEmpty means all day.",
+                    "type": "string",
+                    "example": "17:00"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "valid_from": {
+                    "description": "ValidFrom and ValidUntil optionally limit the rule to a period, i.e. a festival weekend",
+                    "type": "string"
+                },
+                "valid_until": {
+                    "type": "string"
+                },
+                "weekdays": {
+                    "description": "Weekdays the rule applies on, comma separated. Empty means every day.",
+                    "type": "string",
+                    "example": "thu,fri,sat"
+                }
+            }
+        },
+        "PricingRuleRequest": {
+            "type": "object",
+            "required": [
+                "discount_type",
+                "name"
+            ],
+            "properties": {
+                "category": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "beer"
+                },
+                "discount": {
+                    "description": "Discount is only needed for fixed discounts",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/MoneyRequest"
+                        }
+                    ]
+                },
+                "discount_type": {
+                    "enum": [
+                        "percent",
+                        "fixed"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/DiscountType"
+                        }
+                    ]
+                },
+                "drink_id": {
+                    "type": "integer"
+                },
+                "end_time": {
+                    "type": "string",
+                    "maxLength": 5,
+                    "example": "19:00"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Happy hour"
+                },
+                "percent": {
+                    "type": "integer",
+                    "maximum": 100,
+                    "example": 50
+                },
+                "start_time": {
+                    "type": "string",
+                    "maxLength": 5,
+                    "example": "17:00"
+                },
+                "valid_from": {
+                    "type": "string"
+                },
+                "valid_until": {
+                    "type": "string"
+                },
+                "weekdays": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "thu,fri,sat"
+                }
+            }
+        },
+        "Problem": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/Code"
+                        }
+                    ],
+                    "example": "order_not_found"
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "This order does not exist"
+                },
+                "errors": {
+                    "description": "Errors lists every invalid field of a validation_failed problem",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/api/receipt/42"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "orderservice/abcdef-000001"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Not Found"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "urn:ordersystem:problem:order_not_found"
+                }
+            }
+        },
+        "ReceiptPreview": {
+            "type": "object",
+            "properties": {
+                "template": {
+                    "description": "Template in text/template syntax, the current template is used if empty",
+                    "type": "string",
+                    "example": "# {{.Venue}} - Order {{.Order.ID}}: {{.Total}}"
+                }
+            }
+        },
+        "ReceiptStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "written",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "ReceiptPending",
+                "ReceiptWritten",
+                "ReceiptFailed"
+            ]
+        },
+        "Restock": {
+            "type": "object",
+            "properties": {
+                "quantity": {
+                    "description": "Quantity is added to the stock, drinks without stock start tracking it",
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "Role": {
+            "type": "string",
+            "enum": [
+                "customer",
+                "bartender",
+                "admin"
+            ],
+            "x-enum-varnames": [
+                "RoleCustomer",
+                "RoleBartender",
+                "RoleAdmin"
+            ]
+        },
+        "ServingAction": {
+            "type": "string",
+            "enum": [
+                "allow",
+                "flag",
+                "reject"
+            ],
+            "x-enum-varnames": [
+                "ServingAllow",
+                "ServingFlag",
+                "ServingReject"
+            ]
+        },
+        "ServingDecision": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "enum": [
+                        "allow",
+                        "flag",
+                        "reject"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/ServingAction"
+                        }
+                    ]
+                },
+                "consumed": {
+                    "description": "Consumed is the number of standard drinks the customer ordered within the window before this order",
+                    "type": "number"
+                },
                 "created_at": {
                     "type": "string"
                 },
+                "customer": {
+                    "description": "Customer is the subject of the principal that placed the order",
+                    "type": "string"
+                },
                 "deletedAt": {
                     "$ref": "#/definitions/DeletedAt"
                 },
-                "drink": {
-                    "$ref": "#/definitions/Drink"
+                "id": {
+                    "type": "integer"
                 },
-                "drink_id": {
+                "limit": {
+                    "description": "Limit and Window describe the limit that was exceeded",
+                    "type": "number"
+                },
+                "order_id": {
+                    "description": "OrderID is only set for flagged orders, rejected orders are never stored",
+                    "type": "integer"
+                },
+                "ordered": {
+                    "description": "Ordered is the number of standard drinks of this order",
+                    "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "window": {
+                    "type": "string",
+                    "example": "3h0m0s"
+                }
+            }
+        },
+        "SplitMode": {
+            "type": "string",
+            "enum": [
+                "even",
+                "items"
+            ],
+            "x-enum-varnames": [
+                "SplitEven",
+                "SplitItems"
+            ]
+        },
+        "Tab": {
+            "type": "object",
+            "properties": {
+                "bill_status": {
+                    "description": "BillStatus tells whether the consolidated bill in S3 has been written, it is empty while the tab is open",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/ReceiptStatus"
+                        }
+                    ]
+                },
+                "closed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/DeletedAt"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "Name identifies the patron or table, i.e. \"Anna\" or \"Table 4\"",
+                    "type": "string"
+                },
+                "opened_by": {
+                    "type": "string"
+                },
+                "orders": {
+                    "description": "Relationships\nhas many",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Order"
+                    }
+                },
+                "shares": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/TabShare"
+                    }
+                },
+                "split_mode": {
+                    "$ref": "#/definitions/SplitMode"
+                },
+                "status": {
+                    "$ref": "#/definitions/TabStatus"
+                },
+                "total": {
+                    "description": "Total is the running total while the tab is open and the billed amount once it is closed",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/Money"
+                        }
+                    ]
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "TabAttachment": {
+            "type": "object",
+            "required": [
+                "order_id"
+            ],
+            "properties": {
+                "order_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "TabClosing": {
+            "type": "object",
+            "required": [
+                "split"
+            ],
+            "properties": {
+                "payers": {
+                    "type": "array",
+                    "maxItems": 50,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/TabPayer"
+                    }
+                },
+                "split": {
+                    "enum": [
+                        "even",
+                        "items"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/SplitMode"
+                        }
+                    ]
+                }
+            }
+        },
+        "TabOpening": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Table 4"
+                }
+            }
+        },
+        "TabPayer": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "item_ids": {
+                    "description": "ItemIDs are the ids of the order items the payer pays, only used when splitting by line items",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "TabShare": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "$ref": "#/definitions/Money"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/DeletedAt"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "items": {
+                    "description": "many to many, only set when the bill is split by line items",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/OrderItem"
+                    }
+                },
+                "payer": {
+                    "type": "string"
+                },
+                "tab_id": {
+                    "description": "Relationships\nforeign key",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "TabStatus": {
+            "type": "string",
+            "enum": [
+                "open",
+                "closed"
+            ],
+            "x-enum-varnames": [
+                "TabOpen",
+                "TabClosed"
+            ]
+        },
+        "User": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/DeletedAt"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "role": {
+                    "$ref": "#/definitions/Role"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "Voucher": {
+            "type": "object",
+            "properties": {
+                "batch_id": {
                     "description": "Relationships\nforeign key",
                     "type": "integer"
                 },
+                "code": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/DeletedAt"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "max_redemptions": {
+                    "type": "integer"
+                },
+                "redemptions": {
+                    "description": "Redemptions counts the active orders the code has been redeemed on, cancelled orders give it back",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "VoucherBatch": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/DeletedAt"
+                },
+                "discount": {
+                    "$ref": "#/definitions/Money"
+                },
+                "discount_type": {
+                    "description": "DiscountType selects whether Percent or Discount is taken off one unit of the drink",
+                    "enum": [
+                        "percent",
+                        "fixed"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/DiscountType"
+                        }
+                    ]
+                },
+                "drink_id": {
+                    "description": "DrinkID limits the vouchers to a single drink, empty means any drink",
+                    "type": "integer"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
+                "max_redemptions": {
+                    "description": "MaxRedemptions is how often each code can be redeemed, 1 for single-use codes",
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Brewery free beer"
+                },
+                "percent": {
+                    "type": "integer",
+                    "example": 100
+                },
                 "updated_at": {
                     "type": "string"
+                },
+                "vouchers": {
+                    "description": "has many",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Voucher"
+                    }
+                }
+            }
+        },
+        "VoucherUsage": {
+            "type": "object",
+            "properties": {
+                "batch_id": {
+                    "type": "integer"
+                },
+                "codes": {
+                    "type": "integer"
+                },
+                "discounts": {
+                    "description": "Discounts sums up the discount off the list price granted on voucher items, one entry per currency",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Money"
+                    }
+                },
+                "drink_id": {
+                    "type": "integer"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "max_redemptions": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "redemptions": {
+                    "type": "integer"
+                },
+                "used_codes": {
+                    "type": "integer"
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "description": "Token from /api/auth/token as \"Bearer \u003ctoken\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
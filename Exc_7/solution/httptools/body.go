@@ -0,0 +1,56 @@
+package httptools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"ordersystem/validation"
+	"regexp"
+	"strings"
+)
+
+var BadBodyError = errors.New("bad body")
+
+// unknownFieldPrefix starts the error of json.Decoder.DisallowUnknownFields, there is no typed error for it
+const unknownFieldPrefix = "json: unknown field "
+
+// arrayIndex matches indices in the field paths of encoding/json, i.e. items.0.quantity
+var arrayIndex = regexp.MustCompile(`\.(\d+)`)
+
+// DecodeJSON decodes the request body into dst. The body is limited to maxBytes, has to be a single JSON value
+// and must not contain fields dst does not know. Unknown fields and values of the wrong type are returned as
+// validation.Errors, oversized bodies as *http.MaxBytesError and all other problems wrap BadBodyError.
+// The error of an empty body wraps io.EOF as well.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst any, maxBytes int64) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBytes))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(dst)
+	if err != nil {
+		return translateDecodeError(err)
+	}
+	if decoder.More() {
+		return fmt.Errorf("%w: body must contain a single JSON value", BadBodyError)
+	}
+	return nil
+}
+
+func translateDecodeError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return err
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		field := arrayIndex.ReplaceAllString(typeErr.Field, "[$1]")
+		return validation.Errors{{Field: field, Rule: "type", Message: "must be of type " + typeErr.Type.String()}}
+	}
+	if field, ok := strings.CutPrefix(err.Error(), unknownFieldPrefix); ok {
+		return validation.Errors{{Field: strings.Trim(field, `"`), Rule: "unknown", Message: "is not a known field"}}
+	}
+	if errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: body must not be empty (%w)", BadBodyError, err)
+	}
+	return fmt.Errorf("%w: %s", BadBodyError, err.Error())
+}
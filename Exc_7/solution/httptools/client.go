@@ -0,0 +1,65 @@
+package httptools
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const ForwardedForHeader = "X-Forwarded-For"
+
+// ClientIP returns the address of the client. X-Forwarded-For is only honoured if the request comes from one
+// of the trusted proxies, the client is the last address in the chain that is not a trusted proxy itself.
+func ClientIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	remote, err := netip.ParseAddr(host)
+	if err != nil || !isTrusted(remote, trustedProxies) {
+		return host
+	}
+	forwarded := strings.Split(strings.Join(r.Header.Values(ForwardedForHeader), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			// garbage in the chain, everything left of it may be forged
+			break
+		}
+		remote = addr.Unmap()
+		if !isTrusted(remote, trustedProxies) {
+			break
+		}
+	}
+	return remote.String()
+}
+
+func isTrusted(addr netip.Addr, trustedProxies []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, network := range trustedProxies {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// RoutePattern returns method and chi route pattern of the request, i.e. "GET /api/receipt/{orderId}".
+// It also works in middlewares running before the routing. Unknown routes return an empty string.
+func RoutePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return ""
+	}
+	pattern := rctx.RoutePattern()
+	if pattern == "" {
+		pattern = rctx.Routes.Find(chi.NewRouteContext(), r.Method, r.URL.Path)
+	}
+	if pattern == "" {
+		return ""
+	}
+	return r.Method + " " + pattern
+}
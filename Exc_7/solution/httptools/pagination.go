@@ -0,0 +1,79 @@
+package httptools
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const (
+	LimitParam  = "limit"
+	CursorParam = "cursor"
+	SortParam   = "sort"
+
+	TotalCountHeader = "X-Total-Count"
+)
+
+// PageParams are the common query params of paginated list endpoints, i.e. /api/list?limit=10&cursor=abc&sort=-id
+type PageParams struct {
+	Limit  int
+	Cursor string
+	Sort   string
+}
+
+// ParsePageParams reads limit, cursor and sort from the query. The limit defaults to defaultLimit
+// and must not exceed maxLimit.
+func ParsePageParams(r *http.Request, defaultLimit int, maxLimit int) (PageParams, error) {
+	params := PageParams{
+		Limit:  defaultLimit,
+		Cursor: r.URL.Query().Get(CursorParam),
+		Sort:   r.URL.Query().Get(SortParam),
+	}
+	limit := r.URL.Query().Get(LimitParam)
+	if limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed < 1 || parsed > maxLimit {
+			return params, fmt.Errorf("%w: '%s' must be between 1 and %d", BadQueryParamError, LimitParam, maxLimit)
+		}
+		params.Limit = parsed
+	}
+	return params, nil
+}
+
+// EncodeCursor turns the position of the last element of a page into an opaque token
+func EncodeCursor(position any) (string, error) {
+	raw, err := json.Marshal(position)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// DecodeCursor reads a token created by EncodeCursor into position
+func DecodeCursor(token string, position any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return fmt.Errorf("%w: invalid '%s'", BadQueryParamError, CursorParam)
+	}
+	err = json.Unmarshal(raw, position)
+	if err != nil {
+		return fmt.Errorf("%w: invalid '%s'", BadQueryParamError, CursorParam)
+	}
+	return nil
+}
+
+// SetPaginationHeaders sets X-Total-Count and, if there is a next page, a Link header
+// pointing to the same request with the next cursor
+func SetPaginationHeaders(w http.ResponseWriter, r *http.Request, total int64, nextCursor string) {
+	w.Header().Set(TotalCountHeader, strconv.FormatInt(total, 10))
+	if nextCursor == "" {
+		return
+	}
+	next := *r.URL
+	query := next.Query()
+	query.Set(CursorParam, nextCursor)
+	next.RawQuery = query.Encode()
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+}
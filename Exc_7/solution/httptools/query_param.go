@@ -0,0 +1,40 @@
+package httptools
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var BadQueryParamError = errors.New("bad query param")
+
+// ParseOptionalUintQueryParam parses query param with paramName into uint, i.e. /api?some=1.
+// Returns nil if the param is not set.
+func ParseOptionalUintQueryParam(paramName string, r *http.Request) (*uint, error) {
+	value := r.URL.Query().Get(paramName)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.ParseUint(value, 10, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: '%s' must be a positive number", BadQueryParamError, paramName)
+	}
+	result := uint(parsed)
+	return &result, nil
+}
+
+// ParseOptionalTimeQueryParam parses query param with paramName as RFC 3339 timestamp,
+// i.e. /api?from=2025-11-20T18:00:00Z. Returns nil if the param is not set.
+func ParseOptionalTimeQueryParam(paramName string, r *http.Request) (*time.Time, error) {
+	value := r.URL.Query().Get(paramName)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: '%s' must be a RFC 3339 timestamp", BadQueryParamError, paramName)
+	}
+	return &parsed, nil
+}
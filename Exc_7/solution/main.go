@@ -1,16 +1,32 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
 	"log/slog"
 	"net/http"
+	"ordersystem/auth"
+	"ordersystem/config"
+	"ordersystem/outbox"
+	"ordersystem/pricing"
+	"ordersystem/ratelimit"
 	"ordersystem/repository"
 	"ordersystem/rest"
+	"ordersystem/serving"
 	"ordersystem/storage"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	_ "ordersystem/docs"
 
@@ -20,47 +36,201 @@ import (
 
 // @title				Order System
 // @description			This system enables drink orders and should not be used for the forbidden Hungover Games.
+// @securityDefinitions.apikey	BearerAuth
+// @in							header
+// @name						Authorization
+// @description				Token from /api/auth/token as "Bearer <token>"
+// @securityDefinitions.apikey	ApiKeyAuth
+// @in							header
+// @name						X-API-Key
 func main() {
+	// load and validate the config from file, env and flags
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if cfg.PrintConfig {
+		err = config.Print(os.Stdout, cfg)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+	// SIGTERM of a rolling update or Ctrl+C start the graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// background workers stop after the http server, so requests in flight can still use them
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	var workers sync.WaitGroup
+
 	// connect to s3
-	s3, err := storage.CreateS3client()
+	s3, err := storage.CreateS3client(cfg.S3)
 	if err != nil {
 		log.Fatalln(err)
 	}
 	// connect to db
-	db, err := repository.NewDatabaseHandler()
+	db, err := repository.NewDatabaseHandler(cfg.Database)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	// load receipt template
+	renderer, err := storage.CreateReceiptRenderer(s3, cfg.Receipt)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	workers.Go(func() { renderer.Run(workerCtx) })
+	linker, err := storage.CreateReceiptLinker(s3, cfg.S3, cfg.Receipt)
 	if err != nil {
 		log.Fatalln(err)
 	}
 	// prepopulate data
-	err = repository.Prepopulate(db, s3)
+	err = repository.Prepopulate(db, s3, renderer)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	// write receipts in the background
+	dispatcher := outbox.NewDispatcher(db, s3, renderer)
+	workers.Go(func() { dispatcher.Run(workerCtx) })
+	// happy hours and other pricing rules
+	pricingEngine, err := pricing.CreateEngine(cfg.Pricing)
 	if err != nil {
 		log.Fatalln(err)
 	}
+	// responsible serving limits
+	servingPolicy, err := serving.CreatePolicy(cfg.Serving)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	// bearer tokens and api keys
+	authenticator, err := auth.CreateAuthenticator(cfg.Auth)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	// limit requests per client, optionally shared by all replicas through the db
+	limiter, err := ratelimit.CreateLimiter(cfg.RateLimit, db)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	workers.Go(func() { limiter.Run(workerCtx) })
 	r := chi.NewRouter()
+	// request ids are reported in error responses
+	r.Use(middleware.RequestID)
+	// request durations per route pattern for /metrics
+	r.Use(rest.InstrumentRequests)
 	r.Use(middleware.Logger)
-	// allow local cors
+	// allow cors of the configured origins
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"http://localhost", "http://localhost:3000"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Origin", "cache-control", "expires", "pragma"},
-		ExposedHeaders:   []string{"Content-Disposition"},
+		AllowedOrigins:   cfg.Server.CORSOrigins,
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Origin", "X-API-Key", "X-Request-Id", "Idempotency-Key", "Last-Event-ID", "cache-control", "expires", "pragma"},
+		ExposedHeaders:   []string{"Content-Disposition", "Link", "X-Total-Count", "Idempotent-Replayed", "WWW-Authenticate", "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "X-Request-Id"},
 		AllowCredentials: true,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	}))
+	// failed authentication attempts are limited per client ip before the credentials are checked
+	r.Use(rest.Authenticate(authenticator, limiter))
+	r.Use(rest.RateLimit(limiter))
+	r.NotFound(rest.NotFound)
+	r.MethodNotAllowed(rest.MethodNotAllowed)
 
-	// Menu Routes
-	r.Get("/api/menu", rest.GetMenu(db))
-	// Order Routes
-	r.Get("/api/order/all", rest.GetOrders(db))
-	r.Get("/api/order/totalled", rest.GetOrdersTotal(db))
-	r.Get("/api/receipt/{orderId}", rest.GetReceiptFile(db, s3))
-	r.Post("/api/order", rest.PostOrder(db, s3))
+	// Public Routes
+	r.Get("/api/menu", rest.GetMenu(db, pricingEngine))
+	r.Post("/api/auth/token", rest.PostToken(db, authenticator))
+	// Customer Routes
+	r.Group(func(r chi.Router) {
+		r.Use(rest.RequireRole(auth.RoleCustomer))
+		r.With(rest.Idempotency(db, rest.DefaultIdempotencyRetention)).Post("/api/order", rest.PostOrder(db, pricingEngine, servingPolicy))
+		r.Get("/api/receipt/{orderId}", rest.GetReceiptFile(db, s3, renderer, linker))
+		r.Post("/api/tab", rest.OpenTab(db))
+		r.Get("/api/tab/{tabId}", rest.GetTab(db))
+		r.Post("/api/tab/{tabId}/orders", rest.AttachOrder(db))
+		r.Get("/api/tab/{tabId}/bill", rest.GetTabBill(db, s3))
+	})
+	// Bartender Routes
+	r.Group(func(r chi.Router) {
+		r.Use(rest.RequireRole(auth.RoleBartender))
+		r.Get("/api/order/all", rest.GetOrders(db))
+		r.Get("/api/order/totalled", rest.GetOrdersTotal(db))
+		r.Get("/api/order/stream", rest.StreamOrders(db, ctx.Done()))
+		r.Patch("/api/order/{orderId}/status", rest.PatchOrderStatus(db))
+		r.Delete("/api/order/{orderId}", rest.CancelOrder(db))
+		r.Post("/api/tab/{tabId}/close", rest.CloseTab(db))
+		r.Post("/api/menu/{drinkId}/restock", rest.RestockDrink(db))
+		r.Get("/api/pricing/rules", rest.GetPricingRules(db))
+		r.Get("/api/serving/decisions", rest.GetServingDecisions(db))
+	})
+	// Admin Routes
+	r.Group(func(r chi.Router) {
+		r.Use(rest.RequireRole(auth.RoleAdmin))
+		r.Post("/api/menu", rest.PostDrink(db))
+		r.Put("/api/menu/{drinkId}", rest.PutDrink(db))
+		r.Delete("/api/menu/{drinkId}", rest.DeleteDrink(db))
+		r.Post("/api/receipt/preview", rest.PreviewReceipt(renderer))
+		r.Post("/api/order/{orderId}/restore", rest.RestoreOrder(db))
+		r.Post("/api/auth/users", rest.PostUser(db))
+		r.Post("/api/pricing/rules", rest.PostPricingRule(db))
+		r.Delete("/api/pricing/rules/{ruleId}", rest.DeletePricingRule(db))
+		r.Post("/api/voucher/batches", rest.PostVoucherBatch(db))
+		r.Get("/api/voucher/batches/{batchId}", rest.GetVoucherBatch(db))
+		r.Get("/api/voucher/usage", rest.GetVoucherUsage(db))
+	})
 	// OpenAPI Routes
 	r.Get("/openapi/*", httpSwagger.WrapHandler)
 
+	// probes and metrics are served in front of the api middlewares, so they are neither logged nor rate limited
+	var draining atomic.Bool
+	root := http.NewServeMux()
+	root.Handle("GET /healthz", rest.GetHealth())
+	root.Handle("GET /readyz", rest.GetReadiness(db, s3, &draining))
+	root.Handle("GET /metrics", promhttp.Handler())
+	root.Handle("/", r)
+
+	server := &http.Server{Addr: cfg.Server.Addr, Handler: root}
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
 	slog.Info("⚡⚡⚡ Order System is up and running ⚡⚡⚡")
-	err = http.ListenAndServe(":3000", r)
-	if err != nil {
+	select {
+	case err = <-serverErr:
 		log.Fatal(err)
+	case <-ctx.Done():
+		// a second signal kills the process right away
+		stop()
+	}
+	shutdown(server, &draining, stopWorkers, &workers, db, cfg.Server.ShutdownTimeout)
+}
+
+// shutdown reports the replica as not ready, stops accepting connections and waits for requests in flight,
+// then stops the background workers and closes the database pool. Everything has to finish within timeout.
+func shutdown(server *http.Server, draining *atomic.Bool, stopWorkers context.CancelFunc, workers *sync.WaitGroup,
+	db *repository.DatabaseHandler, timeout time.Duration) {
+	slog.Info("Shutting down", slog.Duration("timeout", timeout))
+	draining.Store(true)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := server.Shutdown(ctx)
+	if err != nil {
+		slog.Error("Requests did not finish in time", slog.String("error", err.Error()))
+	}
+	stopWorkers()
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.Error("Background workers did not stop in time")
+	}
+	err = db.Close()
+	if err != nil {
+		slog.Error("Unable to close database", slog.String("error", err.Error()))
 	}
+	slog.Info("Order System stopped")
 }
@@ -1,8 +1,74 @@
 package model
 
+import (
+	"ordersystem/money"
+
+	"gorm.io/gorm"
+)
+
 type Drink struct {
 	Base
-	Name        string  `json:"name" gorm:"unique,not null"`
-	Price       float32 `json:"price"`
-	Description string  `json:"description"`
+	Name        string      `json:"name" gorm:"unique;not null"`
+	Price       money.Money `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	Description string      `json:"description"`
+	// Category groups drinks for pricing rules, i.e. beer or wine
+	Category string `json:"category" gorm:"not null;default:'';index"`
+	// StandardDrinks is the alcohol of one serving in standard drink units, 0 for non-alcoholic drinks
+	StandardDrinks float64 `json:"standard_drinks" gorm:"not null;default:0" example:"2"`
+	// Stock is the number of servings left. Drinks without stock are not tracked and never sell out.
+	Stock *uint64 `json:"stock,omitempty"`
+	// LowStockThreshold flags the drink as low on stock once its stock drops to the threshold
+	LowStockThreshold uint64 `json:"low_stock_threshold" gorm:"not null;default:0"`
+	LowStock          bool   `json:"low_stock" gorm:"-"`
+	// EffectivePrice is the price after pricing rules at the time the menu is requested
+	EffectivePrice *money.Money `json:"effective_price,omitempty" gorm:"-"`
+	// PricingRule is the name of the rule the effective price comes from
+	PricingRule string `json:"pricing_rule,omitempty" gorm:"-"`
+}
+
+// IsLowOnStock reports whether the stock of a tracked drink is at or below its threshold
+func (d *Drink) IsLowOnStock() bool {
+	return d.Stock != nil && *d.Stock <= d.LowStockThreshold
+}
+
+func (d *Drink) AfterFind(*gorm.DB) error {
+	d.LowStock = d.IsLowOnStock()
+	return nil
+}
+
+func (d *Drink) AfterSave(*gorm.DB) error {
+	d.LowStock = d.IsLowOnStock()
+	return nil
+}
+
+// Webmodel DO NOT USE IN DB
+// DrinkRequest is what clients send to add or update a drink
+type DrinkRequest struct {
+	Name           string       `json:"name" validate:"required,max=100" example:"Beer"`
+	Price          MoneyRequest `json:"price" validate:"valid,nonnegative"`
+	Description    string       `json:"description" validate:"max=1000"`
+	Category       string       `json:"category" validate:"max=50" example:"beer"`
+	StandardDrinks float64      `json:"standard_drinks" validate:"nonnegative,max=20" example:"2"`
+	// Stock is only taken when the drink is added, see Restock
+	Stock             *uint64 `json:"stock,omitempty"`
+	LowStockThreshold uint64  `json:"low_stock_threshold"`
+}
+
+// ToDrink creates the drink to be stored
+func (d *DrinkRequest) ToDrink() *Drink {
+	return &Drink{
+		Name:              d.Name,
+		Price:             d.Price.Money(),
+		Description:       d.Description,
+		Category:          d.Category,
+		StandardDrinks:    d.StandardDrinks,
+		Stock:             d.Stock,
+		LowStockThreshold: d.LowStockThreshold,
+	}
+}
+
+// Webmodel DO NOT USE IN DB
+type Restock struct {
+	// Quantity is added to the stock, drinks without stock start tracking it
+	Quantity uint64 `json:"quantity" validate:"min=1"`
 }
@@ -1,7 +1,12 @@
 package model
 
+import "ordersystem/money"
+
 // Webmodel DO NOT USE IN DB
 type DrinkOrderTotal struct {
 	DrinkID            uint64 `json:"drink_id" gorm:"column:drink_id"`
 	TotalAmountOrdered uint64 `json:"total_amount_ordered" gorm:"column:total_amount_ordered"`
+	// GrossRevenue is based on the unit prices at the time the orders were placed
+	GrossRevenue     money.Money `json:"gross_revenue" gorm:"embedded;embeddedPrefix:gross_revenue_"`
+	AverageUnitPrice money.Money `json:"average_unit_price" gorm:"embedded;embeddedPrefix:average_unit_price_"`
 }
@@ -0,0 +1,8 @@
+package model
+
+// Webmodel DO NOT USE IN DB
+type Health struct {
+	Status string `json:"status" example:"ok"`
+	// Checks holds the result of every dependency, ok or unavailable
+	Checks map[string]string `json:"checks,omitempty"`
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+type IdempotencyStatus string
+
+const (
+	IdempotencyInProgress IdempotencyStatus = "in_progress"
+	IdempotencyCompleted  IdempotencyStatus = "completed"
+)
+
+// IdempotencyKey stores the response of the first request sent with an Idempotency-Key header,
+// so retries of the same request can be answered without processing it again.
+// Keys are scoped to the principal, so clients never collide on the same key.
+type IdempotencyKey struct {
+	Principal string    `gorm:"primarykey;size:255"`
+	Key       string    `gorm:"primarykey;size:255"`
+	CreatedAt time.Time `gorm:"not null"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	// LockedUntil is the lease of the request in progress. Once it has passed, a retry takes over the key,
+	// i.e. when the replica processing the first request was killed.
+	LockedUntil time.Time `gorm:"not null"`
+	// Claim is a random token of the request holding the key, so a request whose key has been taken over
+	// cannot complete or release the key of the request that took over
+	Claim string `gorm:"not null;default:''"`
+	// RequestHash detects reuse of a key for a different request
+	RequestHash string            `gorm:"not null"`
+	Status      IdempotencyStatus `gorm:"not null"`
+	// Response of the first request, set once completed
+	ResponseStatus      int
+	ResponseContentType string
+	ResponseBody        []byte
+}
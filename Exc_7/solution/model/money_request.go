@@ -0,0 +1,45 @@
+package model
+
+import "ordersystem/money"
+
+// Webmodel DO NOT USE IN DB
+// MoneyRequest is an amount as clients send it, i.e. {"amount": "1.40", "currency": "EUR"}. The amount is only
+// parsed when the request is validated, so an invalid amount is reported with the path of its field.
+type MoneyRequest struct {
+	Amount string `json:"amount" example:"1.40"`
+	// Currency defaults to EUR
+	Currency string `json:"currency,omitempty" example:"EUR"`
+}
+
+// Parse returns the amount as money
+func (m MoneyRequest) Parse() (money.Money, error) {
+	currency := m.Currency
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	return money.Parse(m.Amount, currency)
+}
+
+// IsValid reports whether the amount can be parsed in a known currency
+func (m MoneyRequest) IsValid() bool {
+	_, err := m.Parse()
+	return err == nil
+}
+
+// IsNegative reports whether a valid amount is below zero
+func (m MoneyRequest) IsNegative() bool {
+	parsed, err := m.Parse()
+	return err == nil && parsed.IsNegative()
+}
+
+// Money returns the parsed amount of a validated request, the zero value if there is none
+func (m *MoneyRequest) Money() money.Money {
+	if m == nil {
+		return money.Money{}
+	}
+	parsed, err := m.Parse()
+	if err != nil {
+		return money.Money{}
+	}
+	return parsed
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"ordersystem/validation"
+	"testing"
+)
+
+func TestMoneyRequestValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		price     MoneyRequest
+		wantRule  string
+		wantMinor int64
+	}{
+		{"valid", MoneyRequest{Amount: "1.40", Currency: "EUR"}, "", 140},
+		{"currency defaults", MoneyRequest{Amount: "2"}, "", 200},
+		{"free", MoneyRequest{Amount: "0"}, "", 0},
+		{"not a number", MoneyRequest{Amount: "abc"}, "valid", 0},
+		{"too many decimals", MoneyRequest{Amount: "1.405"}, "valid", 0},
+		{"unknown currency", MoneyRequest{Amount: "1.40", Currency: "XXX"}, "valid", 0},
+		{"missing amount", MoneyRequest{}, "valid", 0},
+		{"negative", MoneyRequest{Amount: "-1.40"}, "nonnegative", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := DrinkRequest{Name: "Beer", Price: tt.price}
+			errs := validation.Check(&request)
+			if tt.wantRule == "" {
+				if len(errs) > 0 {
+					t.Fatalf("Check() = %v, want no errors", errs)
+				}
+				if got := request.Price.Money().Amount; got != tt.wantMinor {
+					t.Errorf("Money() = %d, want %d", got, tt.wantMinor)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Field != "price" || errs[0].Rule != tt.wantRule {
+				t.Fatalf("Check() = %v, want price to break %s", errs, tt.wantRule)
+			}
+		})
+	}
+}
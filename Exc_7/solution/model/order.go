@@ -1,37 +1,76 @@
 package model
 
-import (
-	"fmt"
-	"time"
-)
+import "ordersystem/money"
 
-const (
-	orderFilename = "order_%d.md"
+type Order struct {
+	Base
+	Status             OrderStatus `json:"status" gorm:"not null;default:placed;index"`
+	CancellationReason string      `json:"cancellation_reason,omitempty"`
+	// CreatedBy is the subject of the principal that placed the order
+	CreatedBy string `json:"created_by" gorm:"not null;default:'';index"`
+	// VoucherCode is the voucher redeemed on the order, optional
+	VoucherCode string `json:"voucher_code,omitempty" gorm:"not null;default:''"`
+	// ServingFlagged marks orders the responsible serving policy flagged for the bar staff
+	ServingFlagged bool `json:"serving_flagged,omitempty" gorm:"not null;default:false"`
+	// ReceiptStatus tells whether the receipt in S3 is up-to-date with the order
+	ReceiptStatus ReceiptStatus `json:"receipt_status" gorm:"not null;default:written"`
+	// Relationships
+	// foreign key, optional
+	TabID *uint `json:"tab_id,omitempty" gorm:"index"`
+	// has many
+	Items         []OrderItem         `json:"items"`
+	StatusHistory []OrderStatusChange `json:"status_history,omitempty"`
+}
 
-	markdownTemplate = `
-# Order: %d
+// Total sums up all line items of the order. All items of an order share the same currency.
+func (o *Order) Total() (money.Money, error) {
+	var total money.Money
+	for _, item := range o.Items {
+		lineTotal, err := item.LineTotal()
+		if err != nil {
+			return money.Money{}, err
+		}
+		total, err = total.Add(lineTotal)
+		if err != nil {
+			return money.Money{}, err
+		}
+	}
+	return total, nil
+}
 
-| Created At | Drink ID | Amount |
-|------------|----------|--------|
-| %s         | %d       | %d     |
+// TotalQuantity sums up the quantities of all line items of the order
+func (o *Order) TotalQuantity() uint64 {
+	var quantity uint64
+	for _, item := range o.Items {
+		quantity += item.Quantity
+	}
+	return quantity
+}
 
-Thanks for drinking with us!
-`
-)
+// IsCancelled reports whether the order has been cancelled, i.e. soft deleted
+func (o *Order) IsCancelled() bool {
+	return o.Status == OrderStatusCancelled || o.DeletedAt.Valid
+}
 
-type Order struct {
-	Base
-	Amount uint64 `json:"amount"`
-	// Relationships
-	// foreign key
-	DrinkID uint  `json:"drink_id" gorm:"not null"`
-	Drink   Drink `json:"drink"`
+// Webmodel DO NOT USE IN DB
+// NewOrder is what clients send to place an order, ids, timestamps, prices and the status are set by the server
+type NewOrder struct {
+	TabID       *uint          `json:"tab_id,omitempty"`
+	VoucherCode string         `json:"voucher_code,omitempty" validate:"max=64"`
+	Items       []NewOrderItem `json:"items" validate:"min=1,max=50"`
 }
 
-func (o *Order) ToMarkdown() string {
-	return fmt.Sprintf(markdownTemplate, o.ID, o.CreatedAt.Format(time.Stamp), o.DrinkID, o.Amount)
+// Webmodel DO NOT USE IN DB
+type NewOrderItem struct {
+	DrinkID  uint   `json:"drink_id" validate:"required" example:"1"`
+	Quantity uint64 `json:"quantity" validate:"min=1,max=100" example:"2"`
 }
 
-func (o *Order) GetFilename() string {
-	return fmt.Sprintf(orderFilename, o.ID)
+// ToOrder creates the order to be stored
+func (o *NewOrder) ToOrder() *Order {
+	order := &Order{TabID: o.TabID, VoucherCode: o.VoucherCode, Items: make([]OrderItem, len(o.Items))}
+	for i, item := range o.Items {
+		order.Items[i] = OrderItem{DrinkID: item.DrinkID, Quantity: item.Quantity}
+	}
+	return order
 }
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+type OrderEventType string
+
+const (
+	OrderCreated OrderEventType = "order.created"
+	OrderChanged OrderEventType = "order.changed"
+)
+
+// OrderEvent is written in the same transaction as the order change. Ids are handed out on insert, so a
+// transaction with a lower id can commit after one with a higher id. The Sequence is therefore handed out once the
+// event is committed, in the order events become visible, and clients of the order stream resume after the last
+// sequence they received when reconnecting.
+type OrderEvent struct {
+	ID uint64 `json:"id" gorm:"primarykey"`
+	// Sequence is 0 until the event has been sequenced
+	Sequence  uint64         `json:"sequence" gorm:"not null;default:0;index"`
+	CreatedAt time.Time      `json:"created_at"`
+	Type      OrderEventType `json:"type" gorm:"not null"`
+	OrderID   uint           `json:"order_id" gorm:"not null;index"`
+	// Payload is the order as JSON after the change
+	Payload []byte `json:"payload" gorm:"type:jsonb;not null"`
+}
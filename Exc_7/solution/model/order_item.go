@@ -0,0 +1,31 @@
+package model
+
+import "ordersystem/money"
+
+// OrderItem is a single line of an order, i.e. 3x Beer
+type OrderItem struct {
+	Base
+	Quantity uint64 `json:"quantity"`
+	// UnitPrice is the price charged, the list price of the menu after pricing rules when the order is placed
+	UnitPrice money.Money `json:"unit_price" gorm:"embedded;embeddedPrefix:unit_price_"`
+	ListPrice money.Money `json:"list_price" gorm:"embedded;embeddedPrefix:list_price_"`
+	// StandardDrinks of one unit as they were when the order was placed, so editing the drink keeps past consumption
+	StandardDrinks float64 `json:"standard_drinks" gorm:"not null;default:0"`
+	// PricingRuleID and PricingRuleName record the rule that discounted the item, the name is kept
+	// as it was when the order was placed
+	PricingRuleID   *uint  `json:"pricing_rule_id,omitempty"`
+	PricingRuleName string `json:"pricing_rule,omitempty" gorm:"not null;default:''"`
+	// VoucherID is set on the single unit the voucher of the order was redeemed on
+	VoucherID *uint `json:"voucher_id,omitempty" gorm:"index"`
+	// VoucherDiscount is what the voucher took off the unit price after pricing rules, for the voucher usage report
+	VoucherDiscount money.Money `json:"-" gorm:"embedded;embeddedPrefix:voucher_discount_"`
+	// Relationships
+	// foreign keys
+	OrderID uint  `json:"order_id" gorm:"not null;index"`
+	DrinkID uint  `json:"drink_id" gorm:"not null"`
+	Drink   Drink `json:"drink"`
+}
+
+func (i *OrderItem) LineTotal() (money.Money, error) {
+	return i.UnitPrice.Mul(i.Quantity)
+}
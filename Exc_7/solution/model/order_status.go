@@ -0,0 +1,67 @@
+package model
+
+type OrderStatus string
+
+const (
+	OrderStatusPlaced    OrderStatus = "placed"
+	OrderStatusPreparing OrderStatus = "preparing"
+	OrderStatusServed    OrderStatus = "served"
+	OrderStatusPaid      OrderStatus = "paid"
+	OrderStatusCancelled OrderStatus = "cancelled"
+)
+
+// orderTransitions lists all statuses an order may move to from a given status
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPlaced:    {OrderStatusPreparing, OrderStatusCancelled},
+	OrderStatusPreparing: {OrderStatusServed, OrderStatusCancelled},
+	OrderStatusServed:    {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:      {},
+	OrderStatusCancelled: {},
+}
+
+// IsValid reports whether s is a known order status
+func (s OrderStatus) IsValid() bool {
+	_, ok := orderTransitions[s]
+	return ok
+}
+
+// IsFinal reports whether no further transitions are possible from s
+func (s OrderStatus) IsFinal() bool {
+	return s.IsValid() && len(orderTransitions[s]) == 0
+}
+
+// CanTransitionTo reports whether an order in status s may move to next
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsUnserved reports whether the drinks of an order in status s have not been served yet,
+// so their stock can be given back when the order is cancelled
+func (s OrderStatus) IsUnserved() bool {
+	return s == OrderStatusPlaced || s == OrderStatusPreparing
+}
+
+// OrderStatusChange is one entry of the status history of an order
+type OrderStatusChange struct {
+	Base
+	FromStatus OrderStatus `json:"from_status"`
+	ToStatus   OrderStatus `json:"to_status" gorm:"not null"`
+	// Relationships
+	// foreign key
+	OrderID uint `json:"order_id" gorm:"not null;index"`
+}
+
+// Webmodel DO NOT USE IN DB
+type OrderStatusUpdate struct {
+	Status OrderStatus `json:"status" validate:"required,valid"`
+}
+
+// Webmodel DO NOT USE IN DB
+type OrderCancellation struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
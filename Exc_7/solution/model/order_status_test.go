@@ -0,0 +1,45 @@
+package model
+
+import "testing"
+
+func TestCanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from OrderStatus
+		to   OrderStatus
+		want bool
+	}{
+		{OrderStatusPlaced, OrderStatusPreparing, true},
+		{OrderStatusPlaced, OrderStatusCancelled, true},
+		{OrderStatusPreparing, OrderStatusServed, true},
+		{OrderStatusPreparing, OrderStatusCancelled, true},
+		{OrderStatusServed, OrderStatusPaid, true},
+		{OrderStatusServed, OrderStatusCancelled, true},
+		// statuses cannot be skipped
+		{OrderStatusPlaced, OrderStatusServed, false},
+		{OrderStatusPlaced, OrderStatusPaid, false},
+		{OrderStatusPreparing, OrderStatusPaid, false},
+		// or go back
+		{OrderStatusPreparing, OrderStatusPlaced, false},
+		{OrderStatusServed, OrderStatusPreparing, false},
+		{OrderStatusPaid, OrderStatusServed, false},
+		// or stay
+		{OrderStatusPlaced, OrderStatusPlaced, false},
+		{OrderStatusServed, OrderStatusServed, false},
+		// final statuses cannot be left
+		{OrderStatusPaid, OrderStatusCancelled, false},
+		{OrderStatusCancelled, OrderStatusPlaced, false},
+		{OrderStatusCancelled, OrderStatusPaid, false},
+		// unknown statuses
+		{OrderStatusPlaced, "delivered", false},
+		{"delivered", OrderStatusPaid, false},
+		{"", OrderStatusPlaced, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.from)+" to "+string(tt.to), func(t *testing.T) {
+			got := tt.from.CanTransitionTo(tt.to)
+			if got != tt.want {
+				t.Errorf("%q.CanTransitionTo(%q) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+type ReceiptStatus string
+
+const (
+	ReceiptPending ReceiptStatus = "pending"
+	ReceiptWritten ReceiptStatus = "written"
+	ReceiptFailed  ReceiptStatus = "failed"
+)
+
+const (
+	OutboxTopicReceipt = "receipt"
+	OutboxTopicTabBill = "tab_bill"
+)
+
+// OutboxMessage is written in the same transaction as the change it announces and is
+// processed asynchronously by the outbox dispatcher, i.e. to write the receipt of an order
+type OutboxMessage struct {
+	Base
+	Topic   string `gorm:"not null"`
+	OrderID uint   `gorm:"not null;index"`
+	// TabID is set instead of the OrderID for tab bills
+	TabID         uint      `gorm:"not null;default:0;index"`
+	Attempts      int       `gorm:"not null;default:0"`
+	NextAttemptAt time.Time `gorm:"not null;index"`
+	LastError     string
+	// ProcessedAt is set once the message has been handled successfully
+	ProcessedAt *time.Time `gorm:"index"`
+	// FailedAt is set once the dispatcher gave up retrying
+	FailedAt *time.Time
+}
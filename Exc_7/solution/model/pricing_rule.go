@@ -0,0 +1,72 @@
+package model
+
+import (
+	"ordersystem/money"
+	"time"
+)
+
+type DiscountType string
+
+const (
+	// DiscountPercent takes a percentage off the list price
+	DiscountPercent DiscountType = "percent"
+	// DiscountFixed takes a fixed amount off the list price
+	DiscountFixed DiscountType = "fixed"
+)
+
+// PricingRule discounts drinks during a time window, i.e. a happy hour or a festival special.
+// Times are interpreted in the timezone of the venue.
+type PricingRule struct {
+	Base
+	Name string `json:"name" gorm:"not null"`
+	// ValidFrom and ValidUntil optionally limit the rule to a period, i.e. a festival weekend
+	ValidFrom  *time.Time `json:"valid_from,omitempty"`
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+	// Weekdays the rule applies on, comma separated. Empty means every day.
+	Weekdays string `json:"weekdays,omitempty" example:"thu,fri,sat"`
+	// StartTime and EndTime form a daily window, an end before the start wraps past midnight.
+	// Empty means all day.
+	StartTime string `json:"start_time,omitempty" example:"17:00"`
+	EndTime   string `json:"end_time,omitempty" example:"19:00"`
+	// DrinkID and Category select the discounted drinks, empty selectors match every drink
+	DrinkID  *uint  `json:"drink_id,omitempty"`
+	Category string `json:"category,omitempty" example:"beer"`
+	// DiscountType selects whether Percent or Discount is taken off the list price
+	DiscountType DiscountType `json:"discount_type" gorm:"not null" enums:"percent,fixed"`
+	Percent      uint64       `json:"percent,omitempty" example:"50"`
+	Discount     money.Money  `json:"discount" gorm:"embedded;embeddedPrefix:discount_"`
+}
+
+// Webmodel DO NOT USE IN DB
+// PricingRuleRequest is what clients send to add a pricing rule, weekdays, times and the discount are checked by the pricing engine
+type PricingRuleRequest struct {
+	Name         string       `json:"name" validate:"required,max=100" example:"Happy hour"`
+	ValidFrom    *time.Time   `json:"valid_from,omitempty"`
+	ValidUntil   *time.Time   `json:"valid_until,omitempty"`
+	Weekdays     string       `json:"weekdays,omitempty" validate:"max=50" example:"thu,fri,sat"`
+	StartTime    string       `json:"start_time,omitempty" validate:"max=5" example:"17:00"`
+	EndTime      string       `json:"end_time,omitempty" validate:"max=5" example:"19:00"`
+	DrinkID      *uint        `json:"drink_id,omitempty"`
+	Category     string       `json:"category,omitempty" validate:"max=50" example:"beer"`
+	DiscountType DiscountType `json:"discount_type" validate:"required,oneof=percent fixed" enums:"percent,fixed"`
+	Percent      uint64       `json:"percent,omitempty" validate:"max=100" example:"50"`
+	// Discount is only needed for fixed discounts
+	Discount *MoneyRequest `json:"discount,omitempty" validate:"valid,nonnegative"`
+}
+
+// ToPricingRule creates the pricing rule to be stored
+func (r *PricingRuleRequest) ToPricingRule() *PricingRule {
+	return &PricingRule{
+		Name:         r.Name,
+		ValidFrom:    r.ValidFrom,
+		ValidUntil:   r.ValidUntil,
+		Weekdays:     r.Weekdays,
+		StartTime:    r.StartTime,
+		EndTime:      r.EndTime,
+		DrinkID:      r.DrinkID,
+		Category:     r.Category,
+		DiscountType: r.DiscountType,
+		Percent:      r.Percent,
+		Discount:     r.Discount.Money(),
+	}
+}
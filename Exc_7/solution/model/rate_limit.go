@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// RateLimitBucket is a token bucket of a client shared by all replicas
+type RateLimitBucket struct {
+	// Key is made of route and client
+	Key        string    `gorm:"primaryKey"`
+	Tokens     float64   `gorm:"not null"`
+	RefilledAt time.Time `gorm:"not null;index"`
+}
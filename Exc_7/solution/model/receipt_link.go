@@ -0,0 +1,9 @@
+package model
+
+import "time"
+
+// Webmodel DO NOT USE IN DB
+type ReceiptLink struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,7 @@
+package model
+
+// Webmodel DO NOT USE IN DB
+type ReceiptPreview struct {
+	// Template in text/template syntax, the current template is used if empty
+	Template string `json:"template" example:"# {{.Venue}} - Order {{.Order.ID}}: {{.Total}}"`
+}
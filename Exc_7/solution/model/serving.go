@@ -0,0 +1,33 @@
+package model
+
+type ServingAction string
+
+const (
+	// ServingAllow lets the order pass
+	ServingAllow ServingAction = "allow"
+	// ServingFlag places the order but flags it for the bar staff
+	ServingFlag ServingAction = "flag"
+	// ServingReject refuses the order
+	ServingReject ServingAction = "reject"
+)
+
+func (a ServingAction) IsValid() bool {
+	return a == ServingAllow || a == ServingFlag || a == ServingReject
+}
+
+// ServingDecision records why the responsible serving policy flagged or rejected an order
+type ServingDecision struct {
+	Base
+	// Customer is the subject of the principal that placed the order
+	Customer string        `json:"customer" gorm:"not null;index"`
+	Action   ServingAction `json:"action" gorm:"not null;index" enums:"allow,flag,reject"`
+	// Consumed is the number of standard drinks the customer ordered within the window before this order
+	Consumed float64 `json:"consumed"`
+	// Ordered is the number of standard drinks of this order
+	Ordered float64 `json:"ordered"`
+	// Limit and Window describe the limit that was exceeded
+	Limit  float64 `json:"limit"`
+	Window string  `json:"window" example:"3h0m0s"`
+	// OrderID is only set for flagged orders, rejected orders are never stored
+	OrderID *uint `json:"order_id,omitempty" gorm:"index"`
+}
@@ -0,0 +1,98 @@
+package model
+
+import (
+	"ordersystem/money"
+	"time"
+)
+
+type TabStatus string
+
+const (
+	TabOpen   TabStatus = "open"
+	TabClosed TabStatus = "closed"
+)
+
+type SplitMode string
+
+const (
+	// SplitEven divides the total evenly among all payers
+	SplitEven SplitMode = "even"
+	// SplitItems lets every payer pay the line items assigned to them
+	SplitItems SplitMode = "items"
+)
+
+// Tab collects the orders of a patron over an evening, they are paid together when the tab is closed
+type Tab struct {
+	Base
+	// Name identifies the patron or table, i.e. "Anna" or "Table 4"
+	Name     string     `json:"name" gorm:"not null"`
+	Status   TabStatus  `json:"status" gorm:"not null;default:open;index"`
+	OpenedBy string     `json:"opened_by" gorm:"not null;default:''"`
+	ClosedAt *time.Time `json:"closed_at,omitempty"`
+	// Total is the running total while the tab is open and the billed amount once it is closed
+	Total     money.Money `json:"total" gorm:"embedded;embeddedPrefix:total_"`
+	SplitMode SplitMode   `json:"split_mode,omitempty" gorm:"not null;default:''"`
+	// BillStatus tells whether the consolidated bill in S3 has been written, it is empty while the tab is open
+	BillStatus ReceiptStatus `json:"bill_status,omitempty" gorm:"not null;default:''"`
+	// Relationships
+	// has many
+	Orders []Order    `json:"orders"`
+	Shares []TabShare `json:"shares,omitempty"`
+}
+
+// TabShare is the part of the bill a single payer has to pay
+type TabShare struct {
+	Base
+	Payer  string      `json:"payer" gorm:"not null"`
+	Amount money.Money `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	// Relationships
+	// foreign key
+	TabID uint `json:"tab_id" gorm:"not null;index"`
+	// many to many, only set when the bill is split by line items
+	Items []OrderItem `json:"items,omitempty" gorm:"many2many:tab_share_items"`
+}
+
+// Webmodel DO NOT USE IN DB
+type TabOpening struct {
+	Name string `json:"name" validate:"required,max=100" example:"Table 4"`
+}
+
+// Webmodel DO NOT USE IN DB
+type TabAttachment struct {
+	OrderID uint `json:"order_id" validate:"required"`
+}
+
+// Webmodel DO NOT USE IN DB
+type TabClosing struct {
+	Split  SplitMode  `json:"split" validate:"required,oneof=even items" enums:"even,items"`
+	Payers []TabPayer `json:"payers" validate:"min=1,max=50"`
+}
+
+// Webmodel DO NOT USE IN DB
+type TabPayer struct {
+	Name string `json:"name" validate:"required,max=100"`
+	// ItemIDs are the ids of the order items the payer pays, only used when splitting by line items
+	ItemIDs []uint `json:"item_ids,omitempty"`
+}
+
+// OrdersTotal sums up all orders on the tab that have not been cancelled. Empty tabs total zero in the default currency.
+func (t *Tab) OrdersTotal() (money.Money, error) {
+	var total money.Money
+	for _, order := range t.Orders {
+		if order.IsCancelled() {
+			continue
+		}
+		orderTotal, err := order.Total()
+		if err != nil {
+			return money.Money{}, err
+		}
+		total, err = total.Add(orderTotal)
+		if err != nil {
+			return money.Money{}, err
+		}
+	}
+	if total.Currency == "" {
+		return money.New(0, money.DefaultCurrency), nil
+	}
+	return total, nil
+}
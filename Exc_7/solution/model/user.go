@@ -0,0 +1,36 @@
+package model
+
+import (
+	"ordersystem/auth"
+	"time"
+)
+
+// User can log in with username and password to obtain a bearer token
+type User struct {
+	Base
+	Username     string    `json:"username" gorm:"unique;not null"`
+	Role         auth.Role `json:"role" gorm:"not null"`
+	PasswordHash string    `json:"-" gorm:"not null"`
+}
+
+// Webmodel DO NOT USE IN DB
+type Credentials struct {
+	Username string `json:"username" validate:"required,max=100"`
+	Password string `json:"password" validate:"required,max=1000"`
+}
+
+// Webmodel DO NOT USE IN DB
+type NewUser struct {
+	Username string `json:"username" validate:"required,max=100"`
+	// Password needs at least auth.MinPasswordLength characters
+	Password string    `json:"password" validate:"min=8,max=1000"`
+	Role     auth.Role `json:"role" validate:"required,valid" enums:"customer,bartender,admin"`
+}
+
+// Webmodel DO NOT USE IN DB
+type AccessToken struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type" example:"Bearer"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Role        auth.Role `json:"role"`
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"ordersystem/money"
+	"time"
+)
+
+// VoucherBatch is a set of voucher codes handed out together, i.e. free beer vouchers of a sponsor
+type VoucherBatch struct {
+	Base
+	Name string `json:"name" gorm:"not null" example:"Brewery free beer"`
+	// DrinkID limits the vouchers to a single drink, empty means any drink
+	DrinkID *uint `json:"drink_id,omitempty"`
+	// MaxRedemptions is how often each code can be redeemed, 1 for single-use codes
+	MaxRedemptions uint64     `json:"max_redemptions" gorm:"not null" example:"1"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	// DiscountType selects whether Percent or Discount is taken off one unit of the drink
+	DiscountType DiscountType `json:"discount_type" gorm:"not null" enums:"percent,fixed"`
+	Percent      uint64       `json:"percent,omitempty" example:"100"`
+	Discount     money.Money  `json:"discount" gorm:"embedded;embeddedPrefix:discount_"`
+	// has many
+	Vouchers []Voucher `json:"vouchers,omitempty" gorm:"foreignKey:BatchID"`
+}
+
+// Voucher is a single code of a batch
+type Voucher struct {
+	Base
+	Code           string `json:"code" gorm:"not null;uniqueIndex"`
+	MaxRedemptions uint64 `json:"max_redemptions" gorm:"not null"`
+	// Redemptions counts the active orders the code has been redeemed on, cancelled orders give it back
+	Redemptions uint64 `json:"redemptions" gorm:"not null;default:0"`
+	// Relationships
+	// foreign key
+	BatchID uint          `json:"batch_id" gorm:"not null;index"`
+	Batch   *VoucherBatch `json:"-"`
+}
+
+// IsExpired reports whether the batch can no longer be redeemed at the given time
+func (b *VoucherBatch) IsExpired(at time.Time) bool {
+	return b.ExpiresAt != nil && !at.Before(*b.ExpiresAt)
+}
+
+// Webmodel DO NOT USE IN DB
+type NewVoucherBatch struct {
+	Name string `json:"name" validate:"required,max=100" example:"Brewery free beer"`
+	// Count is the number of codes to generate
+	Count uint64 `json:"count" validate:"min=1,max=1000" example:"50"`
+	// Prefix is put in front of every code, i.e. the name of the sponsor
+	Prefix         string     `json:"prefix,omitempty" validate:"max=12" example:"BREW"`
+	DrinkID        *uint      `json:"drink_id,omitempty"`
+	MaxRedemptions uint64     `json:"max_redemptions" validate:"min=1" example:"1"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	// DiscountType defaults to a free drink, i.e. 100 percent off
+	DiscountType DiscountType `json:"discount_type,omitempty" enums:"percent,fixed"`
+	Percent      uint64       `json:"percent,omitempty" validate:"max=100" example:"100"`
+	// Discount is only needed for fixed discounts
+	Discount *MoneyRequest `json:"discount,omitempty" validate:"valid,nonnegative"`
+}
+
+// Webmodel DO NOT USE IN DB
+type VoucherUsage struct {
+	BatchID        uint       `json:"batch_id"`
+	Name           string     `json:"name"`
+	DrinkID        *uint      `json:"drink_id,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	Codes          uint64     `json:"codes"`
+	UsedCodes      uint64     `json:"used_codes"`
+	MaxRedemptions uint64     `json:"max_redemptions"`
+	Redemptions    uint64     `json:"redemptions"`
+	// Discounts sums up the discount off the list price granted on voucher items, one entry per currency
+	Discounts []money.Money `json:"discounts" gorm:"-"`
+}
@@ -0,0 +1,212 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const DefaultCurrency = "EUR"
+
+// currencyExponents maps supported ISO 4217 currency codes to their number of minor unit digits
+var currencyExponents = map[string]int{
+	"EUR": 2,
+	"USD": 2,
+	"GBP": 2,
+	"CHF": 2,
+	"CZK": 2,
+	"HUF": 2,
+	"JPY": 0,
+}
+
+var (
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrCurrencyMismatch = errors.New("currencies do not match")
+	ErrInvalidAmount    = errors.New("invalid decimal amount")
+	ErrOverflow         = errors.New("amount is out of range")
+)
+
+// Money is an exact amount in minor units of a currency, i.e. 140 EUR cents for 1.40 EUR.
+// In the db it is stored as two columns, embed it with a prefix:
+//
+//	Price money.Money `gorm:"embedded;embeddedPrefix:price_"`
+//
+// In JSON it is encoded as {"amount": "1.40", "currency": "EUR"}.
+type Money struct {
+	Amount   int64  `json:"amount" gorm:"not null;default:0" swaggertype:"string" example:"1.40"`
+	Currency string `json:"currency" gorm:"type:char(3);not null;default:'EUR'" example:"EUR"`
+}
+
+// New creates Money from minor units
+func New(minorUnits int64, currency string) Money {
+	return Money{Amount: minorUnits, Currency: currency}
+}
+
+// Parse parses a decimal string like "1.40" into Money of the given currency.
+// More decimal places than the currency has minor units are rejected.
+func Parse(amount string, currency string) (Money, error) {
+	exponent, ok := currencyExponents[currency]
+	if !ok {
+		return Money{}, fmt.Errorf("%w '%s'", ErrUnknownCurrency, currency)
+	}
+	amount = strings.TrimSpace(amount)
+	negative := strings.HasPrefix(amount, "-")
+	amount = strings.TrimPrefix(amount, "-")
+	whole, fraction, _ := strings.Cut(amount, ".")
+	if whole == "" || len(fraction) > exponent || strings.ContainsAny(whole+fraction, "+-") {
+		return Money{}, fmt.Errorf("%w '%s'", ErrInvalidAmount, amount)
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+	minorUnits, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w '%s'", ErrInvalidAmount, amount)
+	}
+	if negative {
+		minorUnits = -minorUnits
+	}
+	return New(minorUnits, currency), nil
+}
+
+// FromFloat converts a legacy float price, rounding to the nearest minor unit
+func FromFloat(amount float64, currency string) (Money, error) {
+	exponent, ok := currencyExponents[currency]
+	if !ok {
+		return Money{}, fmt.Errorf("%w '%s'", ErrUnknownCurrency, currency)
+	}
+	return New(int64(math.Round(amount*math.Pow10(exponent))), currency), nil
+}
+
+// MinorUnitFactor returns 10^exponent of the currency, i.e. 100 for EUR
+func MinorUnitFactor(currency string) (int64, error) {
+	exponent, ok := currencyExponents[currency]
+	if !ok {
+		return 0, fmt.Errorf("%w '%s'", ErrUnknownCurrency, currency)
+	}
+	return int64(math.Pow10(exponent)), nil
+}
+
+// IsValid reports whether the currency is supported
+func (m Money) IsValid() bool {
+	_, ok := currencyExponents[m.Currency]
+	return ok
+}
+
+// IsNegative reports whether the amount is below zero
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Add sums up two amounts of the same currency. The zero value can be added to any currency.
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency == "" {
+		return other, nil
+	}
+	if other.Currency != "" && other.Currency != m.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	sum := m.Amount + other.Amount
+	if (other.Amount > 0 && sum < m.Amount) || (other.Amount < 0 && sum > m.Amount) {
+		return Money{}, fmt.Errorf("%w: %s plus %s", ErrOverflow, m, other)
+	}
+	return New(sum, m.Currency), nil
+}
+
+// Mul multiplies the amount, i.e. unit price times quantity
+func (m Money) Mul(factor uint64) (Money, error) {
+	if factor > math.MaxInt64 || (factor > 0 && (m.Amount > math.MaxInt64/int64(factor) || m.Amount < math.MinInt64/int64(factor))) {
+		return Money{}, fmt.Errorf("%w: %s times %d", ErrOverflow, m, factor)
+	}
+	return New(m.Amount*int64(factor), m.Currency), nil
+}
+
+// Split divides the amount into parts that add up to the amount again.
+// The remaining minor units are spread over the first parts, i.e. 10.00 into 3 is 3.34, 3.33, 3.33
+// and -1.00 into 3 is -0.34, -0.33, -0.33.
+func (m Money) Split(parts int) []Money {
+	if parts <= 0 {
+		return nil
+	}
+	shares := make([]Money, parts)
+	share := m.Amount / int64(parts)
+	// the remainder has the sign of the amount
+	remainder := m.Amount % int64(parts)
+	unit := int64(1)
+	if remainder < 0 {
+		remainder, unit = -remainder, -1
+	}
+	for i := range shares {
+		amount := share
+		if int64(i) < remainder {
+			amount += unit
+		}
+		shares[i] = New(amount, m.Currency)
+	}
+	return shares
+}
+
+// Decimal formats the amount as decimal string without currency, i.e. "1.40".
+// The zero value, i.e. the unused discount of a percent rule, is "0".
+func (m Money) Decimal() (string, error) {
+	if m == (Money{}) {
+		return "0", nil
+	}
+	exponent, ok := currencyExponents[m.Currency]
+	if !ok {
+		return "", fmt.Errorf("%w '%s'", ErrUnknownCurrency, m.Currency)
+	}
+	if exponent == 0 {
+		return strconv.FormatInt(m.Amount, 10), nil
+	}
+	sign := ""
+	// as unsigned the smallest amount can be negated as well
+	amount := uint64(m.Amount)
+	if m.Amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	factor := uint64(math.Pow10(exponent))
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/factor, exponent, amount%factor), nil
+}
+
+// String formats the amount with currency, i.e. "1.40 EUR". Amounts of unknown currencies are shown in minor units.
+func (m Money) String() string {
+	decimal, err := m.Decimal()
+	if err != nil {
+		decimal = strconv.FormatInt(m.Amount, 10)
+	}
+	return decimal + " " + m.Currency
+}
+
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	decimal, err := m.Decimal()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(moneyJSON{Amount: decimal, Currency: m.Currency})
+}
+
+// UnmarshalJSON reads {"amount": "1.40", "currency": "EUR"}, the currency defaults to DefaultCurrency
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw moneyJSON
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+	if raw.Currency == "" {
+		raw.Currency = DefaultCurrency
+	}
+	parsed, err := Parse(raw.Amount, raw.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
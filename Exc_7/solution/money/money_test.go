@@ -0,0 +1,169 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		currency string
+		want     Money
+		wantErr  error
+	}{
+		{"whole and fraction", "1.40", "EUR", New(140, "EUR"), nil},
+		{"without fraction", "2", "EUR", New(200, "EUR"), nil},
+		{"short fraction", "2.5", "EUR", New(250, "EUR"), nil},
+		{"negative", "-0.05", "USD", New(-5, "USD"), nil},
+		{"surrounding spaces", " 3.00 ", "EUR", New(300, "EUR"), nil},
+		{"currency without minor units", "150", "JPY", New(150, "JPY"), nil},
+		{"too many decimal places", "1.405", "EUR", Money{}, ErrInvalidAmount},
+		{"decimal places for JPY", "1.5", "JPY", Money{}, ErrInvalidAmount},
+		{"missing whole part", ".50", "EUR", Money{}, ErrInvalidAmount},
+		{"double sign", "--1", "EUR", Money{}, ErrInvalidAmount},
+		{"plus sign", "+1", "EUR", Money{}, ErrInvalidAmount},
+		{"letters", "1.4a", "EUR", Money{}, ErrInvalidAmount},
+		{"out of range", "92233720368547758.08", "EUR", Money{}, ErrInvalidAmount},
+		{"unknown currency", "1.00", "XXX", Money{}, ErrUnknownCurrency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.amount, tt.currency)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse(%q, %q) error = %v, want %v", tt.amount, tt.currency, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q, %q) = %v, want %v", tt.amount, tt.currency, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		name    string
+		money   Money
+		want    string
+		wantErr error
+	}{
+		{"cents", New(140, "EUR"), "1.40", nil},
+		{"below one", New(5, "EUR"), "0.05", nil},
+		{"negative", New(-140, "EUR"), "-1.40", nil},
+		{"negative below one", New(-5, "USD"), "-0.05", nil},
+		{"currency without minor units", New(150, "JPY"), "150", nil},
+		{"smallest amount", New(math.MinInt64, "EUR"), "-92233720368547758.08", nil},
+		{"zero value", Money{}, "0", nil},
+		{"unknown currency", New(140, "XXX"), "", ErrUnknownCurrency},
+		{"amount without currency", New(140, ""), "", ErrUnknownCurrency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.money.Decimal()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Decimal() of %#v error = %v, want %v", tt.money, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Decimal() of %#v = %q, want %q", tt.money, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name  string
+		money Money
+		parts int
+		want  []int64
+	}{
+		{"even", New(900, "EUR"), 3, []int64{300, 300, 300}},
+		{"remainder to the first parts", New(1000, "EUR"), 3, []int64{334, 333, 333}},
+		{"two cents left", New(1001, "EUR"), 3, []int64{334, 334, 333}},
+		{"more parts than cents", New(2, "EUR"), 4, []int64{1, 1, 0, 0}},
+		{"single part", New(999, "EUR"), 1, []int64{999}},
+		{"negative remainder to the first parts", New(-100, "EUR"), 3, []int64{-34, -33, -33}},
+		{"negative two cents left", New(-1001, "EUR"), 3, []int64{-334, -334, -333}},
+		{"no parts", New(999, "EUR"), 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares := tt.money.Split(tt.parts)
+			var got []int64
+			var sum int64
+			for _, share := range shares {
+				if share.Currency != tt.money.Currency {
+					t.Errorf("share %v has another currency than %v", share, tt.money)
+				}
+				got = append(got, share.Amount)
+				sum += share.Amount
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("Split(%d) of %v = %v, want %v", tt.parts, tt.money, got, tt.want)
+			}
+			if len(shares) > 0 && sum != tt.money.Amount {
+				t.Errorf("Split(%d) of %v adds up to %d", tt.parts, tt.money, sum)
+			}
+		})
+	}
+}
+
+func TestAdd(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    Money
+		want    Money
+		wantErr error
+	}{
+		{"same currency", New(140, "EUR"), New(60, "EUR"), New(200, "EUR"), nil},
+		{"zero value plus amount", Money{}, New(60, "EUR"), New(60, "EUR"), nil},
+		{"amount plus zero value", New(140, "EUR"), Money{}, New(140, "EUR"), nil},
+		{"negative", New(140, "EUR"), New(-200, "EUR"), New(-60, "EUR"), nil},
+		{"other currency", New(140, "EUR"), New(60, "USD"), Money{}, ErrCurrencyMismatch},
+		{"overflow", New(math.MaxInt64, "EUR"), New(1, "EUR"), Money{}, ErrOverflow},
+		{"underflow", New(math.MinInt64, "EUR"), New(-1, "EUR"), Money{}, ErrOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.a.Add(tt.b)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("%v.Add(%v) error = %v, want %v", tt.a, tt.b, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("%v.Add(%v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMul(t *testing.T) {
+	tests := []struct {
+		name    string
+		money   Money
+		factor  uint64
+		want    Money
+		wantErr error
+	}{
+		{"quantity", New(140, "EUR"), 3, New(420, "EUR"), nil},
+		{"zero", New(140, "EUR"), 0, New(0, "EUR"), nil},
+		{"negative", New(-140, "EUR"), 2, New(-280, "EUR"), nil},
+		{"largest amount", New(math.MaxInt64, "EUR"), 1, New(math.MaxInt64, "EUR"), nil},
+		{"overflow", New(math.MaxInt64/2+1, "EUR"), 2, Money{}, ErrOverflow},
+		{"underflow", New(math.MinInt64/2-1, "EUR"), 2, Money{}, ErrOverflow},
+		{"factor out of range", New(1, "EUR"), math.MaxInt64 + 1, Money{}, ErrOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.money.Mul(tt.factor)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("%v.Mul(%d) error = %v, want %v", tt.money, tt.factor, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("%v.Mul(%d) = %v, want %v", tt.money, tt.factor, got, tt.want)
+			}
+		})
+	}
+}
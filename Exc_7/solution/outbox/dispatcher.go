@@ -0,0 +1,143 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"ordersystem/model"
+	"ordersystem/receipt"
+	"ordersystem/repository"
+	"ordersystem/storage"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	pollInterval = 1 * time.Second
+	batchSize    = 20
+	// lease hides claimed messages from other replicas while they are processed
+	lease       = 1 * time.Minute
+	maxAttempts = 10
+	baseBackoff = 2 * time.Second
+	maxBackoff  = 5 * time.Minute
+)
+
+var (
+	receiptsWritten = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ordersystem_receipts_written_total",
+		Help: "Receipts and tab bills written to S3",
+	}, []string{"topic"})
+	receiptsFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ordersystem_receipts_failed_total",
+		Help: "Failed attempts to write receipts and tab bills, retried until the message is given up",
+	}, []string{"topic"})
+)
+
+// Dispatcher processes outbox messages in the background, i.e. writes receipts and tab bills to S3.
+// Every replica of the ordersystem runs its own dispatcher, messages are claimed so each is handled once.
+type Dispatcher struct {
+	db       *repository.DatabaseHandler
+	s3       *minio.Client
+	renderer *receipt.Renderer
+}
+
+func NewDispatcher(db *repository.DatabaseHandler, s3 *minio.Client, renderer *receipt.Renderer) *Dispatcher {
+	return &Dispatcher{db: db, s3: s3, renderer: renderer}
+}
+
+// Run polls for due messages until ctx is cancelled. The message in progress is finished, so a shutdown never
+// interrupts writing a receipt.
+func (d *Dispatcher) Run(ctx context.Context) {
+	slog.Info("Starting outbox dispatcher")
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		d.dispatchDue(ctx)
+		select {
+		case <-ctx.Done():
+			slog.Info("Stopped outbox dispatcher")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Dispatcher) dispatchDue(ctx context.Context) {
+	messages, err := d.db.ClaimOutboxMessages(batchSize, lease)
+	if err != nil {
+		slog.Error("Unable to claim outbox messages", slog.String("error", err.Error()))
+		return
+	}
+	for i := range messages {
+		if ctx.Err() != nil {
+			// unprocessed messages are picked up again once the lease has passed
+			return
+		}
+		d.dispatch(context.WithoutCancel(ctx), &messages[i])
+	}
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context, message *model.OutboxMessage) {
+	err := d.handle(ctx, message)
+	if err == nil {
+		receiptsWritten.WithLabelValues(message.Topic).Inc()
+		err = d.db.CompleteOutboxMessage(message)
+		if err != nil {
+			slog.Error("Unable to complete outbox message", slog.Uint64("id", uint64(message.ID)), slog.String("error", err.Error()))
+		}
+		return
+	}
+	if errors.Is(err, repository.ErrReceiptBusy) {
+		// another replica writes the receipt of the order, ours is written once it has finished
+		err = d.db.DeferOutboxMessage(message, time.Now().Add(pollInterval))
+		if err != nil {
+			slog.Error("Unable to update outbox message", slog.Uint64("id", uint64(message.ID)), slog.String("error", err.Error()))
+		}
+		return
+	}
+	receiptsFailed.WithLabelValues(message.Topic).Inc()
+	attempts := message.Attempts + 1
+	if attempts >= maxAttempts {
+		slog.Error("Giving up on outbox message", slog.Uint64("id", uint64(message.ID)),
+			slog.Int("attempts", attempts), slog.String("error", err.Error()))
+		err = d.db.FailOutboxMessage(message, err)
+	} else {
+		slog.Warn("Outbox message failed, retrying", slog.Uint64("id", uint64(message.ID)),
+			slog.Int("attempts", attempts), slog.String("error", err.Error()))
+		err = d.db.RetryOutboxMessage(message, err, time.Now().Add(backoff(attempts)))
+	}
+	if err != nil {
+		slog.Error("Unable to update outbox message", slog.Uint64("id", uint64(message.ID)), slog.String("error", err.Error()))
+	}
+}
+
+func (d *Dispatcher) handle(ctx context.Context, message *model.OutboxMessage) error {
+	switch message.Topic {
+	case model.OutboxTopicReceipt:
+		// the order is read under the receipt lock, receipts of cancelled orders are written as void
+		return d.db.WriteReceipt(message.OrderID, func(order *model.Order) error {
+			return storage.PutReceipt(ctx, d.s3, d.renderer, order)
+		})
+	case model.OutboxTopicTabBill:
+		tab, err := d.db.GetTab(message.TabID)
+		if err != nil {
+			return err
+		}
+		return storage.PutBill(ctx, d.s3, d.renderer, tab)
+	default:
+		return fmt.Errorf("unknown outbox topic '%s'", message.Topic)
+	}
+}
+
+// backoff doubles the delay with every attempt, up to maxBackoff
+func backoff(attempts int) time.Duration {
+	delay := baseBackoff << (attempts - 1)
+	if delay <= 0 || delay > maxBackoff {
+		return maxBackoff
+	}
+	return delay
+}
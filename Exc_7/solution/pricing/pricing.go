@@ -0,0 +1,219 @@
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"ordersystem/model"
+	"ordersystem/money"
+	"strconv"
+	"strings"
+	"time"
+
+	// timezone database for containers without tzdata
+	_ "time/tzdata"
+)
+
+const defaultTimezone = "Europe/Vienna"
+
+var ErrInvalidRule = errors.New("invalid pricing rule")
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Engine evaluates pricing rules in the timezone of the venue
+type Engine struct {
+	location *time.Location
+}
+
+func NewEngine(location *time.Location) *Engine {
+	return &Engine{location: location}
+}
+
+// Config holds the timezone pricing rules are evaluated in
+type Config struct {
+	Timezone string `yaml:"timezone" env:"PRICING_TIMEZONE" flag:"pricing-timezone" usage:"IANA timezone of the venue"`
+}
+
+func DefaultConfig() Config {
+	return Config{Timezone: defaultTimezone}
+}
+
+// Validate reports all invalid settings
+func (c Config) Validate() error {
+	_, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return fmt.Errorf("PRICING_TIMEZONE: %w", err)
+	}
+	return nil
+}
+
+// CreateEngine creates an engine for the timezone of the config
+func CreateEngine(cfg Config) (*Engine, error) {
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("PRICING_TIMEZONE: %w", err)
+	}
+	return NewEngine(location), nil
+}
+
+// Price returns the effective price of the drink at the given time. Of all matching rules the one
+// with the lowest price wins, without match the list price is returned together with a nil rule.
+func (e *Engine) Price(drink *model.Drink, rules []model.PricingRule, at time.Time) (money.Money, *model.PricingRule) {
+	price := drink.Price
+	var applied *model.PricingRule
+	for i := range rules {
+		rule := &rules[i]
+		if !e.Matches(rule, drink, at) {
+			continue
+		}
+		discounted, ok := discount(drink.Price, rule)
+		if ok && discounted.Amount < price.Amount {
+			price = discounted
+			applied = rule
+		}
+	}
+	return price, applied
+}
+
+// Matches reports whether the rule applies to the drink at the given time
+func (e *Engine) Matches(rule *model.PricingRule, drink *model.Drink, at time.Time) bool {
+	if rule.DrinkID != nil && *rule.DrinkID != drink.ID {
+		return false
+	}
+	if rule.Category != "" && !strings.EqualFold(rule.Category, drink.Category) {
+		return false
+	}
+	if rule.ValidFrom != nil && at.Before(*rule.ValidFrom) {
+		return false
+	}
+	if rule.ValidUntil != nil && !at.Before(*rule.ValidUntil) {
+		return false
+	}
+	local := at.In(e.location)
+	minute := local.Hour()*60 + local.Minute()
+	day := local.Weekday()
+	if rule.StartTime != "" {
+		start, _ := parseClock(rule.StartTime)
+		end, _ := parseClock(rule.EndTime)
+		if end <= start {
+			// the window wraps past midnight, early hours belong to the window of the day before
+			if minute < end {
+				day = (day + 6) % 7
+			} else if minute < start {
+				return false
+			}
+		} else if minute < start || minute >= end {
+			return false
+		}
+	}
+	if rule.Weekdays != "" {
+		days, _ := parseWeekdays(rule.Weekdays)
+		if !days[day] {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks the rule before it is stored
+func Validate(rule *model.PricingRule) error {
+	if rule.Name == "" {
+		return fmt.Errorf("%w: name is missing", ErrInvalidRule)
+	}
+	if rule.ValidFrom != nil && rule.ValidUntil != nil && !rule.ValidFrom.Before(*rule.ValidUntil) {
+		return fmt.Errorf("%w: valid_from must be before valid_until", ErrInvalidRule)
+	}
+	if _, err := parseWeekdays(rule.Weekdays); err != nil {
+		return err
+	}
+	if (rule.StartTime == "") != (rule.EndTime == "") {
+		return fmt.Errorf("%w: start_time and end_time must be set together", ErrInvalidRule)
+	}
+	if rule.StartTime != "" {
+		if _, err := parseClock(rule.StartTime); err != nil {
+			return err
+		}
+		if _, err := parseClock(rule.EndTime); err != nil {
+			return err
+		}
+	}
+	err := ValidateDiscount(rule.DiscountType, rule.Percent, rule.Discount)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidRule, err)
+	}
+	return nil
+}
+
+// ValidateDiscount checks a percent or fixed discount, it is shared by pricing rules and vouchers
+func ValidateDiscount(discountType model.DiscountType, percent uint64, amount money.Money) error {
+	switch discountType {
+	case model.DiscountPercent:
+		if percent == 0 || percent > 100 {
+			return errors.New("percent must be between 1 and 100")
+		}
+	case model.DiscountFixed:
+		if amount.Amount <= 0 || !amount.IsValid() {
+			return errors.New("discount must be positive and in a known currency")
+		}
+	default:
+		return fmt.Errorf("discount_type must be '%s' or '%s'", model.DiscountPercent, model.DiscountFixed)
+	}
+	return nil
+}
+
+// discount applies the discount of the rule to the list price
+func discount(price money.Money, rule *model.PricingRule) (money.Money, bool) {
+	return ApplyDiscount(price, rule.DiscountType, rule.Percent, rule.Discount)
+}
+
+// ApplyDiscount takes a percent or fixed discount off the price, prices never drop below zero.
+// Fixed discounts only apply to prices of the same currency.
+func ApplyDiscount(price money.Money, discountType model.DiscountType, percent uint64, amount money.Money) (money.Money, bool) {
+	switch discountType {
+	case model.DiscountPercent:
+		// round half up to the minor unit
+		remaining := int64(100 - min(percent, 100))
+		return money.New((price.Amount*remaining+50)/100, price.Currency), true
+	case model.DiscountFixed:
+		if amount.Currency != price.Currency {
+			return money.Money{}, false
+		}
+		return money.New(max(price.Amount-amount.Amount, 0), price.Currency), true
+	default:
+		return money.Money{}, false
+	}
+}
+
+// parseClock parses a time of day like "17:30" into minutes since midnight
+func parseClock(value string) (int, error) {
+	hours, minutes, ok := strings.Cut(value, ":")
+	h, errH := strconv.Atoi(hours)
+	m, errM := strconv.Atoi(minutes)
+	if !ok || errH != nil || errM != nil || h < 0 || h > 23 || m < 0 || m > 59 {
+		return 0, fmt.Errorf("%w: '%s' is not a time like 17:30", ErrInvalidRule, value)
+	}
+	return h*60 + m, nil
+}
+
+// parseWeekdays parses comma separated weekdays like "fri,sat"
+func parseWeekdays(value string) (map[time.Weekday]bool, error) {
+	days := map[time.Weekday]bool{}
+	if value == "" {
+		return days, nil
+	}
+	for _, name := range strings.Split(value, ",") {
+		day, ok := weekdayNames[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown weekday '%s', use mon, tue, wed, thu, fri, sat or sun", ErrInvalidRule, name)
+		}
+		days[day] = true
+	}
+	return days, nil
+}
@@ -0,0 +1,57 @@
+package pricing
+
+import (
+	"ordersystem/model"
+	"testing"
+	"time"
+)
+
+func TestMatchesDailyWindow(t *testing.T) {
+	vienna, err := time.LoadLocation("Europe/Vienna")
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := NewEngine(vienna)
+	// 2026-10-16 is a Friday
+	at := func(day int, hour int, minute int) time.Time {
+		return time.Date(2026, time.October, day, hour, minute, 0, 0, vienna)
+	}
+	lateNight := model.PricingRule{Weekdays: "fri", StartTime: "22:00", EndTime: "02:00"}
+	everyNight := model.PricingRule{StartTime: "22:00", EndTime: "02:00"}
+	happyHour := model.PricingRule{Weekdays: "fri", StartTime: "17:00", EndTime: "19:00"}
+	allDay := model.PricingRule{Weekdays: "fri", StartTime: "00:00", EndTime: "00:00"}
+	tests := []struct {
+		name string
+		rule model.PricingRule
+		at   time.Time
+		want bool
+	}{
+		{"before the window", lateNight, at(16, 21, 59), false},
+		{"start of the window", lateNight, at(16, 22, 0), true},
+		{"before midnight", lateNight, at(16, 23, 30), true},
+		{"midnight", lateNight, at(17, 0, 0), true},
+		{"after midnight belongs to the day before", lateNight, at(17, 1, 59), true},
+		{"end of the window", lateNight, at(17, 2, 0), false},
+		{"window of a day that is not listed", lateNight, at(17, 23, 0), false},
+		{"after midnight of a day that is not listed", lateNight, at(16, 1, 0), false},
+		{"after midnight of the day after", lateNight, at(18, 1, 0), false},
+		{"every day after midnight", everyNight, at(18, 0, 30), true},
+		{"every day during the day", everyNight, at(18, 12, 0), false},
+		{"same day window", happyHour, at(16, 18, 59), true},
+		{"end of same day window", happyHour, at(16, 19, 0), false},
+		{"same day window on another day", happyHour, at(17, 18, 0), false},
+		{"equal start and end is all day", allDay, at(16, 12, 0), true},
+		{"equal start and end ends with the day", allDay, at(17, 0, 0), false},
+		{"times in utc are converted to the venue", lateNight, time.Date(2026, time.October, 16, 21, 0, 0, 0, time.UTC), true},
+	}
+	drink := &model.Drink{Base: model.Base{ID: 1}, Category: "beer"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := engine.Matches(&tt.rule, drink, tt.at)
+			if got != tt.want {
+				t.Errorf("Matches(%s %s-%s) at %s = %v, want %v", tt.rule.Weekdays, tt.rule.StartTime, tt.rule.EndTime,
+					tt.at.In(vienna).Format("Mon 15:04"), got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package problem
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"ordersystem/validation"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	// ContentType of RFC 7807 problem details
+	ContentType = "application/problem+json"
+	// RequestIDHeader carries the id of the request, ids sent by clients are kept by middleware.RequestID
+	RequestIDHeader = "X-Request-Id"
+	typePrefix      = "urn:ordersystem:problem:"
+)
+
+// Code identifies the kind of problem, clients can rely on codes not changing
+type Code string
+
+const (
+	CodeInvalidBody        Code = "invalid_body"
+	CodeInvalidParameter   Code = "invalid_parameter"
+	CodeValidationFailed   Code = "validation_failed"
+	CodeBodyTooLarge       Code = "body_too_large"
+	CodeUnauthorized       Code = "unauthorized"
+	CodeForbidden          Code = "forbidden"
+	CodeNotFound           Code = "not_found"
+	CodeMethodNotAllowed   Code = "method_not_allowed"
+	CodeConflict           Code = "conflict"
+	CodeNotAcceptable      Code = "not_acceptable"
+	CodeRateLimited        Code = "rate_limited"
+	CodeInternal           Code = "internal_error"
+	CodeStorageUnavailable Code = "storage_unavailable"
+	CodeUnavailable        Code = "service_unavailable"
+
+	CodeIdempotencyKeyInvalid Code = "idempotency_key_invalid"
+	CodeIdempotencyKeyReused  Code = "idempotency_key_reused"
+	CodeIdempotencyInProgress Code = "idempotency_in_progress"
+
+	CodeOrderNotFound      Code = "order_not_found"
+	CodeEmptyOrder         Code = "empty_order"
+	CodeMixedCurrencies    Code = "mixed_currencies"
+	CodeAmountOverflow     Code = "amount_overflow"
+	CodeIllegalTransition  Code = "illegal_transition"
+	CodeOrderCancelled     Code = "order_cancelled"
+	CodeOrderNotCancelled  Code = "order_not_cancelled"
+	CodeReceiptFailed      Code = "receipt_failed"
+	CodeUnknownFormat      Code = "unknown_format"
+	CodeInvalidTemplate    Code = "invalid_template"
+	CodeDrinkNotFound      Code = "drink_not_found"
+	CodeUnknownDrink       Code = "unknown_drink"
+	CodeInvalidDrink       Code = "invalid_drink"
+	CodeDrinkNameTaken     Code = "drink_name_taken"
+	CodeInsufficientStock  Code = "insufficient_stock"
+	CodeInvalidRestock     Code = "invalid_restock"
+	CodeTabNotFound        Code = "tab_not_found"
+	CodeUnknownTab         Code = "unknown_tab"
+	CodeInvalidTab         Code = "invalid_tab"
+	CodeTabOpen            Code = "tab_open"
+	CodeTabClosed          Code = "tab_closed"
+	CodeTabUnsettled       Code = "tab_unsettled"
+	CodeOrderOnOtherTab    Code = "order_on_other_tab"
+	CodeInvalidSplit       Code = "invalid_split"
+	CodeInvalidUser        Code = "invalid_user"
+	CodeUsernameTaken      Code = "username_taken"
+	CodePasswordTooShort   Code = "password_too_short"
+	CodeInvalidPricingRule Code = "invalid_pricing_rule"
+	CodeRuleNotFound       Code = "pricing_rule_not_found"
+	CodeInvalidBatch       Code = "invalid_voucher_batch"
+	CodeBatchNotFound      Code = "voucher_batch_not_found"
+	CodeUnknownVoucher     Code = "unknown_voucher"
+	CodeVoucherExpired     Code = "voucher_expired"
+	CodeVoucherUsedUp      Code = "voucher_used_up"
+	CodeVoucherNotApplies  Code = "voucher_not_applicable"
+	CodeServingLimit       Code = "serving_limit_exceeded"
+)
+
+// Problem is an RFC 7807 problem detail, extended by a stable code and the id of the request
+type Problem struct {
+	Type      string `json:"type" example:"urn:ordersystem:problem:order_not_found"`
+	Title     string `json:"title" example:"Not Found"`
+	Status    int    `json:"status" example:"404"`
+	Detail    string `json:"detail,omitempty" example:"This order does not exist"`
+	Instance  string `json:"instance,omitempty" example:"/api/receipt/42"`
+	Code      Code   `json:"code" example:"order_not_found"`
+	RequestID string `json:"request_id,omitempty" example:"orderservice/abcdef-000001"`
+	// Errors lists every invalid field of a validation_failed problem
+	Errors validation.Errors `json:"errors,omitempty"`
+}
+
+// New creates a problem with the given status, the title is the text of the status
+func New(status int, code Code, detail string) *Problem {
+	return &Problem{
+		Type:   typePrefix + string(code),
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+func (p *Problem) Error() string {
+	return string(p.Code) + ": " + p.Detail
+}
+
+// Write answers the request with the problem. The instance is the requested path,
+// the request id is taken from the request context.
+func Write(w http.ResponseWriter, r *http.Request, p *Problem) {
+	written := *p
+	written.Instance = r.URL.Path
+	written.RequestID = middleware.GetReqID(r.Context())
+	if written.RequestID != "" {
+		w.Header().Set(RequestIDHeader, written.RequestID)
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(written.Status)
+	err := json.NewEncoder(w).Encode(written)
+	if err != nil {
+		slog.Error("Unable to write problem", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,43 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps the buckets in memory, every replica limits on its own
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]Bucket
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]Bucket{}}
+}
+
+func (s *MemoryStore) TakeRateLimitToken(_ context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bucket, result := limit.Take(s.buckets[key], now)
+	s.buckets[key] = bucket
+	return result, nil
+}
+
+func (s *MemoryStore) PeekRateLimitToken(_ context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, result := limit.Take(s.buckets[key], now)
+	return result, nil
+}
+
+func (s *MemoryStore) SweepRateLimitBuckets(_ context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, bucket := range s.buckets {
+		if bucket.RefilledAt.Before(before) {
+			delete(s.buckets, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRoute configures the limit of all routes without their own limit
+	DefaultRoute = "default"
+	// FailedAuthRoute limits requests with invalid bearer tokens or api keys per client ip. It never falls back
+	// to the default limit, failed attempts are only limited while it is configured.
+	FailedAuthRoute = "failed_auth"
+	defaultLimits   = "default=300/1m,POST /api/order=30/1m,POST /api/auth/token=10/1m,failed_auth=10/1m"
+	sweepInterval   = time.Minute
+	StorePostgres   = "postgres"
+	StoreMemory     = "memory"
+	defaultStore    = StoreMemory
+	routeKeyDivider = "|"
+)
+
+var ErrInvalidLimit = errors.New("invalid rate limit")
+
+// Limit allows Requests per Period, refilled evenly. Up to Requests can be made at once.
+type Limit struct {
+	Requests uint64
+	Period   time.Duration
+}
+
+// Bucket holds the tokens of one client, every request takes one
+type Bucket struct {
+	Tokens     float64
+	RefilledAt time.Time
+}
+
+// Result of taking a token
+type Result struct {
+	Limit     Limit
+	Allowed   bool
+	Remaining uint64
+	// RetryAfter is the time until the next token, only set if the request is not allowed
+	RetryAfter time.Duration
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+}
+
+// Store keeps the buckets of all clients
+type Store interface {
+	// TakeRateLimitToken takes a token from the bucket with the given key
+	TakeRateLimitToken(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+	// PeekRateLimitToken reports whether a token could be taken from the bucket without taking it
+	PeekRateLimitToken(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+	// SweepRateLimitBuckets removes buckets not used since before, they are full anyway
+	SweepRateLimitBuckets(ctx context.Context, before time.Time) error
+}
+
+// Take refills the bucket for the time passed since its last refill and takes a token if there is one.
+// A new bucket, i.e. one never refilled, starts full.
+func (l Limit) Take(bucket Bucket, now time.Time) (Bucket, Result) {
+	capacity := float64(l.Requests)
+	tokens := capacity
+	if !bucket.RefilledAt.IsZero() {
+		tokens = min(capacity, bucket.Tokens+now.Sub(bucket.RefilledAt).Seconds()*l.perSecond())
+	}
+	result := Result{Limit: l, Allowed: tokens >= 1}
+	if result.Allowed {
+		tokens--
+	} else {
+		result.RetryAfter = l.timeFor(1 - tokens)
+	}
+	result.Remaining = uint64(math.Floor(tokens))
+	result.Reset = l.timeFor(capacity - tokens)
+	return Bucket{Tokens: tokens, RefilledAt: now}, result
+}
+
+func (l Limit) perSecond() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// timeFor returns the time it takes to refill the given tokens
+func (l Limit) timeFor(tokens float64) time.Duration {
+	return time.Duration(tokens / l.perSecond() * float64(time.Second))
+}
+
+// Limiter limits requests per route and client. Routes are identified by method and chi route pattern,
+// i.e. "POST /api/order".
+type Limiter struct {
+	store          Store
+	limits         map[string]Limit
+	trustedProxies []netip.Prefix
+}
+
+func NewLimiter(store Store, limits map[string]Limit, trustedProxies []netip.Prefix) *Limiter {
+	return &Limiter{store: store, limits: limits, trustedProxies: trustedProxies}
+}
+
+// Config of the limiter. Limits holds comma separated route=requests/period entries,
+// i.e. "default=300/1m,POST /api/order=30/1m", an empty value disables rate limiting. Store selects where the
+// buckets are kept, "memory" per replica or "postgres" shared by all replicas. TrustedProxies lists the
+// networks of reverse proxies whose X-Forwarded-For header is honoured.
+type Config struct {
+	Limits         string   `yaml:"limits" env:"RATE_LIMITS" flag:"rate-limits" usage:"comma separated route=requests/period limits"`
+	TrustedProxies []string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES" flag:"trusted-proxies" usage:"comma separated networks of reverse proxies"`
+	Store          string   `yaml:"store" env:"RATE_LIMIT_STORE" flag:"rate-limit-store" usage:"where buckets are kept, memory or postgres"`
+}
+
+func DefaultConfig() Config {
+	return Config{Limits: defaultLimits, Store: defaultStore}
+}
+
+// Validate reports all invalid settings
+func (c Config) Validate() error {
+	var errs []error
+	if _, err := parseLimits(c.Limits); err != nil {
+		errs = append(errs, fmt.Errorf("RATE_LIMITS: %w", err))
+	}
+	if _, err := parseNetworks(c.TrustedProxies); err != nil {
+		errs = append(errs, fmt.Errorf("TRUSTED_PROXIES: %w", err))
+	}
+	if c.Store != StoreMemory && c.Store != StorePostgres {
+		errs = append(errs, fmt.Errorf("RATE_LIMIT_STORE must be '%s' or '%s'", StoreMemory, StorePostgres))
+	}
+	return errors.Join(errs...)
+}
+
+// CreateLimiter creates the limiter of the config, the shared store is used if the config selects postgres
+func CreateLimiter(cfg Config, shared Store) (*Limiter, error) {
+	limits, err := parseLimits(cfg.Limits)
+	if err != nil {
+		return nil, fmt.Errorf("RATE_LIMITS: %w", err)
+	}
+	trustedProxies, err := parseNetworks(cfg.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("TRUSTED_PROXIES: %w", err)
+	}
+	var store Store
+	switch cfg.Store {
+	case StoreMemory:
+		store = NewMemoryStore()
+	case StorePostgres:
+		store = shared
+	default:
+		return nil, fmt.Errorf("RATE_LIMIT_STORE must be '%s' or '%s'", StoreMemory, StorePostgres)
+	}
+	return NewLimiter(store, limits, trustedProxies), nil
+}
+
+// Allow takes a token of the client for the route. Routes without limit and without default are always allowed,
+// the result has no limit then.
+func (l *Limiter) Allow(ctx context.Context, route string, client string) (Result, error) {
+	route, limit, ok := l.limitOf(route)
+	if !ok {
+		return Result{Allowed: true}, nil
+	}
+	return l.store.TakeRateLimitToken(ctx, route+routeKeyDivider+client, limit, time.Now())
+}
+
+// Peek reports whether Allow would let the client pass, without taking a token
+func (l *Limiter) Peek(ctx context.Context, route string, client string) (Result, error) {
+	route, limit, ok := l.limitOf(route)
+	if !ok {
+		return Result{Allowed: true}, nil
+	}
+	return l.store.PeekRateLimitToken(ctx, route+routeKeyDivider+client, limit, time.Now())
+}
+
+// limitOf returns the limit of the route, or the default limit if the route has none
+func (l *Limiter) limitOf(route string) (string, Limit, bool) {
+	limit, ok := l.limits[route]
+	if ok || route == FailedAuthRoute {
+		return route, limit, ok
+	}
+	limit, ok = l.limits[DefaultRoute]
+	return DefaultRoute, limit, ok
+}
+
+// TrustedProxies returns the networks of reverse proxies whose X-Forwarded-For header is honoured
+func (l *Limiter) TrustedProxies() []netip.Prefix {
+	return l.trustedProxies
+}
+
+// Run removes unused buckets until the context is cancelled
+func (l *Limiter) Run(ctx context.Context) {
+	var longest time.Duration
+	for _, limit := range l.limits {
+		longest = max(longest, limit.Period)
+	}
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := l.store.SweepRateLimitBuckets(ctx, time.Now().Add(-longest))
+			if err != nil {
+				slog.Error("Unable to sweep rate limit buckets", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// parseLimits parses comma separated limits like "POST /api/order=30/1m"
+func parseLimits(value string) (map[string]Limit, error) {
+	limits := map[string]Limit{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		route, rate, ok := strings.Cut(entry, "=")
+		requests, period, ok2 := strings.Cut(rate, "/")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("%w: '%s' is not route=requests/period", ErrInvalidLimit, entry)
+		}
+		count, err := strconv.ParseUint(strings.TrimSpace(requests), 10, 64)
+		if err != nil || count == 0 {
+			return nil, fmt.Errorf("%w: requests '%s' must be a positive number", ErrInvalidLimit, requests)
+		}
+		duration, err := time.ParseDuration(strings.TrimSpace(period))
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("%w: period '%s' must be a positive duration, i.e. 1m", ErrInvalidLimit, period)
+		}
+		limits[strings.Join(strings.Fields(route), " ")] = Limit{Requests: count, Period: duration}
+	}
+	return limits, nil
+}
+
+// parseNetworks parses networks or addresses like "10.0.0.0/8" and "192.168.1.10"
+func parseNetworks(entries []string) ([]netip.Prefix, error) {
+	var networks []netip.Prefix
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, err
+			}
+			networks = append(networks, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		network, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network.Masked())
+	}
+	return networks, nil
+}
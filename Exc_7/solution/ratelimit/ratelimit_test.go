@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTake(t *testing.T) {
+	now := time.Date(2026, time.October, 16, 20, 0, 0, 0, time.UTC)
+	perSecond := Limit{Requests: 60, Period: time.Minute}
+	slow := Limit{Requests: 10, Period: time.Minute}
+	tests := []struct {
+		name           string
+		limit          Limit
+		bucket         Bucket
+		wantAllowed    bool
+		wantTokens     float64
+		wantRemaining  uint64
+		wantRetryAfter time.Duration
+		wantReset      time.Duration
+	}{
+		{"new bucket starts full", perSecond, Bucket{},
+			true, 59, 59, 0, time.Second},
+		{"last token", perSecond, Bucket{Tokens: 1, RefilledAt: now},
+			true, 0, 0, 0, time.Minute},
+		{"empty bucket", perSecond, Bucket{Tokens: 0, RefilledAt: now},
+			false, 0, 0, time.Second, time.Minute},
+		{"partial token waits for the rest", perSecond, Bucket{Tokens: 0.25, RefilledAt: now},
+			false, 0.25, 0, 750 * time.Millisecond, 59750 * time.Millisecond},
+		{"refilled since the last request", perSecond, Bucket{Tokens: 0, RefilledAt: now.Add(-2500 * time.Millisecond)},
+			true, 1.5, 1, 0, 58500 * time.Millisecond},
+		{"refill is capped at the capacity", perSecond, Bucket{Tokens: 30, RefilledAt: now.Add(-time.Hour)},
+			true, 59, 59, 0, time.Second},
+		{"slow limit refills every 6 seconds", slow, Bucket{Tokens: 0, RefilledAt: now.Add(-3 * time.Second)},
+			false, 0.5, 0, 3 * time.Second, 57 * time.Second},
+		{"slow limit after a full token", slow, Bucket{Tokens: 0, RefilledAt: now.Add(-6 * time.Second)},
+			true, 0, 0, 0, time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket, result := tt.limit.Take(tt.bucket, now)
+			if result.Allowed != tt.wantAllowed {
+				t.Errorf("Allowed = %v, want %v", result.Allowed, tt.wantAllowed)
+			}
+			if math.Abs(bucket.Tokens-tt.wantTokens) > 1e-9 {
+				t.Errorf("Tokens = %v, want %v", bucket.Tokens, tt.wantTokens)
+			}
+			if !bucket.RefilledAt.Equal(now) {
+				t.Errorf("RefilledAt = %v, want %v", bucket.RefilledAt, now)
+			}
+			if result.Remaining != tt.wantRemaining {
+				t.Errorf("Remaining = %d, want %d", result.Remaining, tt.wantRemaining)
+			}
+			// durations are computed with floats
+			if (result.RetryAfter - tt.wantRetryAfter).Abs() > time.Microsecond {
+				t.Errorf("RetryAfter = %v, want %v", result.RetryAfter, tt.wantRetryAfter)
+			}
+			if (result.Reset - tt.wantReset).Abs() > time.Microsecond {
+				t.Errorf("Reset = %v, want %v", result.Reset, tt.wantReset)
+			}
+			if result.Limit != tt.limit {
+				t.Errorf("Limit = %v, want %v", result.Limit, tt.limit)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package receipt
+
+import (
+	_ "embed"
+	"fmt"
+	"ordersystem/model"
+	"ordersystem/money"
+	"text/template"
+	"time"
+)
+
+//go:embed templates/bill.md.tmpl
+var billTemplateContent string
+
+var billTemplate = template.Must(template.New("bill").Option("missingkey=error").Parse(billTemplateContent))
+
+// BillData is available in the bill template
+type BillData struct {
+	Venue    string
+	Tab      *model.Tab
+	ClosedAt string
+	Items    []BillItem
+	Total    money.Money
+	Shares   []BillShare
+}
+
+type BillItem struct {
+	TemplateItem
+	OrderID uint
+}
+
+type BillShare struct {
+	Payer  string
+	Amount money.Money
+	Items  []BillItem
+}
+
+// BillFilename is the object name of the bill of a tab in the orders bucket, i.e. tab_1.md
+func BillFilename(tab *model.Tab) string {
+	return fmt.Sprintf("tab_%d.%s", tab.ID, extensions[Markdown])
+}
+
+// Bill renders the consolidated markdown bill of a closed tab
+func (r *Renderer) Bill(tab *model.Tab) ([]byte, error) {
+	data := BillData{Venue: r.venue, Tab: tab, Total: tab.Total}
+	if tab.ClosedAt != nil {
+		data.ClosedAt = tab.ClosedAt.Format(time.Stamp)
+	}
+	for _, order := range tab.Orders {
+		for _, item := range order.Items {
+			data.Items = append(data.Items, newBillItem(item))
+		}
+	}
+	for _, share := range tab.Shares {
+		billShare := BillShare{Payer: share.Payer, Amount: share.Amount}
+		for _, item := range share.Items {
+			billShare.Items = append(billShare.Items, newBillItem(item))
+		}
+		data.Shares = append(data.Shares, billShare)
+	}
+	return execute(billTemplate, data)
+}
+
+func newBillItem(item model.OrderItem) BillItem {
+	return BillItem{TemplateItem: newTemplateItem(item), OrderID: item.OrderID}
+}
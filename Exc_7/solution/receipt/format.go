@@ -0,0 +1,110 @@
+package receipt
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"ordersystem/model"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Format string
+
+const (
+	Markdown Format = "markdown"
+	HTML     Format = "html"
+	JSON     Format = "json"
+	PDF      Format = "pdf"
+)
+
+var ErrUnknownFormat = errors.New("unknown receipt format, use one of markdown, html, json, pdf")
+
+var contentTypes = map[Format]string{
+	Markdown: "text/markdown",
+	HTML:     "text/html",
+	JSON:     "application/json",
+	PDF:      "application/pdf",
+}
+
+var extensions = map[Format]string{
+	Markdown: "md",
+	HTML:     "html",
+	JSON:     "json",
+	PDF:      "pdf",
+}
+
+// Formats lists all supported formats, the first one is the default
+var Formats = []Format{Markdown, HTML, JSON, PDF}
+
+// ParseFormat parses a format name like "pdf", file extensions like "md" are accepted as well
+func ParseFormat(name string) (Format, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, format := range Formats {
+		if name == string(format) || name == extensions[format] {
+			return format, nil
+		}
+	}
+	return "", ErrUnknownFormat
+}
+
+func (f Format) ContentType() string {
+	return contentTypes[f]
+}
+
+// Filename is the object name of the receipt in the orders bucket, i.e. order_1.pdf
+func (f Format) Filename(order *model.Order) string {
+	return fmt.Sprintf("order_%d.%s", order.ID, extensions[f])
+}
+
+// Negotiate picks the format from an Accept header, honouring q-values.
+// An empty header or */* yields the default format, ok is false if no format is acceptable.
+func Negotiate(accept string) (format Format, ok bool) {
+	if strings.TrimSpace(accept) == "" {
+		return Formats[0], true
+	}
+	type candidate struct {
+		format Format
+		q      float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		for _, format := range Formats {
+			if matchesMediaType(mediaType, format.ContentType()) {
+				candidates = append(candidates, candidate{format: format, q: q})
+				// wildcards only select the default format
+				break
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return "", false
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	return candidates[0].format, true
+}
+
+func matchesMediaType(accepted string, contentType string) bool {
+	if accepted == "*/*" || accepted == contentType {
+		return true
+	}
+	mainType, _, _ := strings.Cut(contentType, "/")
+	return accepted == mainType+"/*"
+}
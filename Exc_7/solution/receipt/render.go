@@ -0,0 +1,58 @@
+package receipt
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"ordersystem/model"
+	"strings"
+)
+
+var htmlTemplate = template.Must(template.New("receipt").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <title>{{.Venue}} - Order {{.Order.ID}}</title>
+</head>
+<body>
+<h1>{{.Venue}} - Order: {{.Order.ID}}</h1>
+<p>Created At: {{.CreatedAt}}</p>
+<p>Status: {{.Order.Status}}</p>
+<table>
+    <tr><th>Drink</th><th>Quantity</th><th>Unit Price</th><th>Line Total</th></tr>
+    {{- range .Items}}
+    <tr><td>{{.Drink.Name}}</td><td>{{.Quantity}}</td><td>{{.UnitPrice}}{{if .PricingRule}} ({{.PricingRule}}, list {{.ListPrice}}){{end}}{{if .Voucher}} (voucher){{end}}</td><td>{{.LineTotal}}</td></tr>
+    {{- end}}
+</table>
+{{- if .Void}}
+<p><strong>VOID</strong> - this order has been cancelled: {{.Order.CancellationReason}}</p>
+{{- end}}
+<p><strong>Total: {{.Total}}</strong></p>
+<p>Thanks for drinking with us!</p>
+</body>
+</html>
+`))
+
+// Render renders the receipt of the order in the given format. Markdown and PDF receipts
+// use the configurable template, the PDF contains the rendered markdown as plain text.
+// HTML receipts always use the built-in htmlTemplate.
+func (r *Renderer) Render(order *model.Order, format Format) ([]byte, error) {
+	switch format {
+	case Markdown:
+		return r.Markdown(order)
+	case JSON:
+		return json.MarshalIndent(order, "", "  ")
+	case HTML:
+		var buf bytes.Buffer
+		err := htmlTemplate.Execute(&buf, newTemplateData(r.venue, order))
+		return buf.Bytes(), err
+	case PDF:
+		markdown, err := r.Markdown(order)
+		if err != nil {
+			return nil, err
+		}
+		return renderPDF(strings.Split(strings.TrimSpace(string(markdown)), "\n")), nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
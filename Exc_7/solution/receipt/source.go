@@ -0,0 +1,28 @@
+package receipt
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// FileSource loads the receipt template from a file, i.e. a mounted config or volume
+type FileSource struct {
+	Path string
+}
+
+func (s FileSource) Load(_ context.Context) (string, string, error) {
+	info, err := os.Stat(s.Path)
+	if err != nil {
+		return "", "", err
+	}
+	content, err := os.ReadFile(s.Path)
+	if err != nil {
+		return "", "", err
+	}
+	return string(content), fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size()), nil
+}
+
+func (s FileSource) Name() string {
+	return "file://" + s.Path
+}
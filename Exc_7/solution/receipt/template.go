@@ -0,0 +1,213 @@
+package receipt
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"log/slog"
+	"ordersystem/model"
+	"ordersystem/money"
+	"sync"
+	"text/template"
+	"time"
+)
+
+const reloadInterval = 10 * time.Second
+
+//go:embed templates/receipt.md.tmpl
+var defaultTemplate string
+
+// TemplateSource loads the receipt template, i.e. from a file or an S3 bucket.
+// The version changes whenever the template changes, so unchanged templates are not parsed again.
+type TemplateSource interface {
+	Load(ctx context.Context) (content string, version string, err error)
+	Name() string
+}
+
+// TemplateData is available in receipt templates, i.e. {{.Venue}} or {{range .Items}}{{.Drink.Name}}{{end}}
+type TemplateData struct {
+	Venue     string
+	Order     *model.Order
+	CreatedAt string
+	Items     []TemplateItem
+	Total     money.Money
+	Void      bool
+}
+
+type TemplateItem struct {
+	Drink     model.Drink
+	Quantity  uint64
+	UnitPrice money.Money
+	ListPrice money.Money
+	// PricingRule is the name of the rule that discounted the item, empty if charged at list price
+	PricingRule string
+	// Voucher tells whether a voucher has been redeemed on the item
+	Voucher   bool
+	LineTotal money.Money
+}
+
+// Renderer renders receipts of a venue with a markdown template that can be reloaded at runtime
+type Renderer struct {
+	venue  string
+	source TemplateSource
+
+	mu      sync.RWMutex
+	tmpl    *template.Template
+	version string
+}
+
+// NewRenderer creates a renderer for the venue. Without source the built-in template is used,
+// otherwise the template is loaded from source and reloaded by Run.
+func NewRenderer(ctx context.Context, venue string, source TemplateSource) (*Renderer, error) {
+	tmpl, err := ParseTemplate(defaultTemplate)
+	if err != nil {
+		return nil, err
+	}
+	renderer := &Renderer{venue: venue, source: source, tmpl: tmpl}
+	if source != nil {
+		err = renderer.reload(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return renderer, nil
+}
+
+// ParseTemplate parses a receipt template and checks it against a sample order
+func ParseTemplate(content string) (*template.Template, error) {
+	tmpl, err := template.New("receipt").Option("missingkey=error").Parse(content)
+	if err != nil {
+		return nil, err
+	}
+	_, err = execute(tmpl, newTemplateData("Sample Venue", SampleOrder()))
+	if err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// Run reloads the template whenever the source changes until ctx is cancelled.
+// Broken templates are logged and the previous template is kept.
+func (r *Renderer) Run(ctx context.Context) {
+	if r.source == nil {
+		return
+	}
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := r.reload(ctx)
+			if err != nil {
+				slog.Error("Unable to reload receipt template", slog.String("source", r.source.Name()),
+					slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+func (r *Renderer) reload(ctx context.Context) error {
+	content, version, err := r.source.Load(ctx)
+	if err != nil {
+		return err
+	}
+	r.mu.RLock()
+	unchanged := version == r.version
+	r.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+	tmpl, err := ParseTemplate(content)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.tmpl = tmpl
+	r.version = version
+	r.mu.Unlock()
+	slog.Info("Loaded receipt template", slog.String("source", r.source.Name()), slog.String("version", version))
+	return nil
+}
+
+// Markdown renders the receipt of the order with the current template
+func (r *Renderer) Markdown(order *model.Order) ([]byte, error) {
+	r.mu.RLock()
+	tmpl := r.tmpl
+	r.mu.RUnlock()
+	return execute(tmpl, newTemplateData(r.venue, order))
+}
+
+// Preview renders the given template against the sample order, an empty template previews the current one
+func (r *Renderer) Preview(content string) ([]byte, error) {
+	if content == "" {
+		return r.Markdown(SampleOrder())
+	}
+	tmpl, err := ParseTemplate(content)
+	if err != nil {
+		return nil, err
+	}
+	return execute(tmpl, newTemplateData(r.venue, SampleOrder()))
+}
+
+func execute(tmpl *template.Template, data any) ([]byte, error) {
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, data)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func newTemplateData(venue string, order *model.Order) TemplateData {
+	// totals of mixed currencies cannot happen for stored orders, see repository.AddOrder
+	total, err := order.Total()
+	if err != nil {
+		slog.Error("Unable to total order", slog.Uint64("id", uint64(order.ID)), slog.String("error", err.Error()))
+	}
+	data := TemplateData{
+		Venue:     venue,
+		Order:     order,
+		CreatedAt: order.CreatedAt.Format(time.Stamp),
+		Total:     total,
+		Void:      order.IsCancelled(),
+	}
+	for _, item := range order.Items {
+		data.Items = append(data.Items, newTemplateItem(item))
+	}
+	return data
+}
+
+func newTemplateItem(item model.OrderItem) TemplateItem {
+	// line totals that overflow have already been logged by order.Total
+	lineTotal, _ := item.LineTotal()
+	return TemplateItem{
+		Drink:       item.Drink,
+		Quantity:    item.Quantity,
+		UnitPrice:   item.UnitPrice,
+		ListPrice:   item.ListPrice,
+		PricingRule: item.PricingRuleName,
+		Voucher:     item.VoucherID != nil,
+		LineTotal:   lineTotal,
+	}
+}
+
+// SampleOrder is used to check and preview templates
+func SampleOrder() *model.Order {
+	beer := model.Drink{Base: model.Base{ID: 1}, Name: "Beer", Price: money.New(200, money.DefaultCurrency)}
+	spritzer := model.Drink{Base: model.Base{ID: 2}, Name: "Spritzer", Price: money.New(140, money.DefaultCurrency)}
+	voucherID := uint(7)
+	return &model.Order{
+		Base:        model.Base{ID: 42, CreatedAt: time.Date(2025, 11, 20, 21, 30, 0, 0, time.UTC)},
+		Status:      model.OrderStatusPlaced,
+		VoucherCode: "BREW-K7QM-X3TA",
+		Items: []model.OrderItem{
+			{Quantity: 3, UnitPrice: money.New(100, money.DefaultCurrency), ListPrice: beer.Price,
+				PricingRuleName: "Happy Hour", DrinkID: beer.ID, Drink: beer},
+			{Quantity: 2, UnitPrice: spritzer.Price, ListPrice: spritzer.Price, DrinkID: spritzer.ID, Drink: spritzer},
+			{Quantity: 1, UnitPrice: money.New(0, money.DefaultCurrency), ListPrice: beer.Price,
+				VoucherID: &voucherID, DrinkID: beer.ID, Drink: beer},
+		},
+	}
+}
@@ -1,13 +1,19 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"log/slog"
 	"ordersystem/model"
-	"ordersystem/secrets"
-	"os"
+	"ordersystem/money"
+	"ordersystem/pricing"
+	"ordersystem/serving"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -16,49 +22,73 @@ type DatabaseHandler struct {
 	dbConn *gorm.DB
 }
 
-func NewDatabaseHandler() (*DatabaseHandler, error) {
+// Config holds the connection settings of the postgres database
+type Config struct {
+	Host     string `yaml:"host" env:"DB_HOST" flag:"db-host" required:"true" usage:"host of the postgres database"`
+	Port     int    `yaml:"port" env:"PGPORT" flag:"db-port" required:"true" usage:"port of the postgres database"`
+	Name     string `yaml:"name" env:"POSTGRES_DB" flag:"db-name" required:"true" usage:"name of the postgres database"`
+	User     string `yaml:"user" env:"POSTGRES_USER" secret:"true" required:"true"`
+	Password string `yaml:"password" env:"POSTGRES_PASSWORD" secret:"true" required:"true"`
+}
+
+// Validate reports all invalid settings
+func (c Config) Validate() error {
+	if c.Port < 1 || c.Port > 65535 {
+		return fmt.Errorf("PGPORT must be a port between 1 and 65535")
+	}
+	return nil
+}
+
+// DSN is the connection string of the database
+func (c Config) DSN() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d", c.Host, c.User, c.Password, c.Name, c.Port)
+}
+
+func NewDatabaseHandler(cfg Config) (*DatabaseHandler, error) {
 	slog.Info("Connecting to database")
-	// connect to db
-	dsn, err := getDsn()
+	// translate unique violations into gorm.ErrDuplicatedKey
+	dbConn, err := gorm.Open(postgres.New(postgres.Config{DSN: cfg.DSN()}), &gorm.Config{TranslateError: true})
+	if err != nil {
+		return nil, err
+	}
+	// observe the duration of every query for /metrics
+	err = dbConn.Use(queryMetrics{})
+	if err != nil {
+		return nil, err
+	}
+	// and the connection pool
+	sqlDB, err := dbConn.DB()
 	if err != nil {
 		return nil, err
 	}
-	dbConn, err := gorm.Open(postgres.New(postgres.Config{DSN: dsn}), &gorm.Config{})
+	err = prometheus.Register(collectors.NewDBStatsCollector(sqlDB, cfg.Name))
 	if err != nil {
 		return nil, err
 	}
 	// create tables and migrate
-	err = dbConn.AutoMigrate(&model.Drink{}, &model.Order{})
+	err = migrate(dbConn)
 	if err != nil {
 		return nil, err
 	}
 	return &DatabaseHandler{dbConn: dbConn}, nil
 }
 
-func getDsn() (string, error) {
-	dbUser, err := secrets.LoadSecretOrEnv("POSTGRES_USER")
+// Ping checks that the database is reachable
+func (db *DatabaseHandler) Ping(ctx context.Context) error {
+	sqlDB, err := db.dbConn.DB()
 	if err != nil {
-		return "", err
+		return err
 	}
-	dbPw, err := secrets.LoadSecretOrEnv("POSTGRES_PASSWORD")
+	return sqlDB.PingContext(ctx)
+}
+
+// Close closes all connections of the pool, the handler must not be used afterwards
+func (db *DatabaseHandler) Close() error {
+	sqlDB, err := db.dbConn.DB()
 	if err != nil {
-		return "", err
-	}
-	dbName, ok := os.LookupEnv("POSTGRES_DB")
-	if !ok {
-		return "", errors.New("environment variable 'POSTGRES_DB' is not set")
-	}
-	dbPort, ok := os.LookupEnv("PGPORT")
-	if !ok {
-		return "", errors.New("environment variable 'PGPORT' is not set")
+		return err
 	}
-	dbHost, ok := os.LookupEnv("DB_HOST")
-	if !ok {
-		return "", errors.New("environment variable 'DB_HOST' is not set")
-	}
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s",
-		dbHost, dbUser, dbPw, dbName, dbPort)
-	return dsn, nil
+	return sqlDB.Close()
 }
 
 func (db *DatabaseHandler) GetDrinks() (drinks []model.Drink, err error) {
@@ -69,16 +99,30 @@ func (db *DatabaseHandler) GetDrinks() (drinks []model.Drink, err error) {
 	return drinks, nil
 }
 
-func (db *DatabaseHandler) GetOrders() (orders []model.Order, err error) {
-	err = db.dbConn.Find(&orders).Error
+// GetOrder returns the order with the given id.
+// Cancelled orders are returned together with ErrOrderCancelled.
+func (db *DatabaseHandler) GetOrder(id uint) (*model.Order, error) {
+	dbOrder, err := db.loadOrder(id)
 	if err != nil {
 		return nil, err
 	}
-	return orders, nil
+	if dbOrder.DeletedAt.Valid {
+		return dbOrder, ErrOrderCancelled
+	}
+	return dbOrder, nil
 }
 
-func (db *DatabaseHandler) GetOrder(id uint) (dbOrder *model.Order, err error) {
-	err = db.dbConn.
+// loadOrder loads the order including soft deleted ones
+func (db *DatabaseHandler) loadOrder(id uint) (*model.Order, error) {
+	return findOrder(db.dbConn, id)
+}
+
+// findOrder loads the order including soft deleted ones within tx
+func findOrder(tx *gorm.DB, id uint) (dbOrder *model.Order, err error) {
+	err = tx.
+		Unscoped().
+		Preload("Items.Drink").
+		Preload("StatusHistory").
 		Where("id = ?", id).
 		First(&dbOrder).Error
 	if err != nil {
@@ -87,18 +131,143 @@ func (db *DatabaseHandler) GetOrder(id uint) (dbOrder *model.Order, err error) {
 	return dbOrder, nil
 }
 
-const totalledStmt = `SELECT drink_id, SUM(amount) AS total_amount_ordered FROM orders WHERE deleted_at IS NULL GROUP BY drink_id ORDER BY drink_id;`
+const totalledStmt = `SELECT order_items.drink_id,
+	SUM(order_items.quantity) AS total_amount_ordered,
+	CAST(SUM(order_items.quantity * order_items.unit_price_amount) AS bigint) AS gross_revenue_amount,
+	order_items.unit_price_currency AS gross_revenue_currency,
+	CAST(ROUND(SUM(order_items.quantity * order_items.unit_price_amount) / NULLIF(SUM(order_items.quantity), 0)) AS bigint) AS average_unit_price_amount,
+	order_items.unit_price_currency AS average_unit_price_currency
+FROM order_items JOIN orders ON orders.id = order_items.order_id
+WHERE orders.deleted_at IS NULL AND order_items.deleted_at IS NULL
+	AND (CAST(@from AS timestamptz) IS NULL OR orders.created_at >= @from)
+	AND (CAST(@to AS timestamptz) IS NULL OR orders.created_at < @to)
+GROUP BY order_items.drink_id, order_items.unit_price_currency
+ORDER BY order_items.drink_id, order_items.unit_price_currency;`
 
-func (db *DatabaseHandler) GetTotalledOrders() (totals []model.DrinkOrderTotal, err error) {
-	err = db.dbConn.Raw(totalledStmt).Scan(&totals).Error
+// GetTotalledOrders sums up quantity and revenue per drink and currency of all orders created in [from, to).
+// Both bounds are optional.
+func (db *DatabaseHandler) GetTotalledOrders(from *time.Time, to *time.Time) (totals []model.DrinkOrderTotal, err error) {
+	err = db.dbConn.Raw(totalledStmt, sql.Named("from", from), sql.Named("to", to)).Scan(&totals).Error
 	if err != nil {
 		return nil, err
 	}
 	return totals, nil
 }
 
-func (db *DatabaseHandler) AddOrder(order *model.Order) (*model.Order, error) {
-	err := db.dbConn.Create(order).Error
+var (
+	ErrEmptyOrder        = errors.New("order has no items")
+	ErrUnknownDrink      = errors.New("order contains unknown drink")
+	ErrMixedCurrencies   = errors.New("all drinks of an order must have the same currency")
+	ErrIllegalTransition = errors.New("illegal order status transition")
+	ErrOrderCancelled    = errors.New("order has been cancelled")
+	ErrOrderNotCancelled = errors.New("order has not been cancelled")
+)
+
+// AddOrder stores the order together with all its items in a single transaction.
+// The unit price of every item is the price of the current menu after pricing rules, the list price and the applied
+// rule are kept on the item. The quantities are taken from the stock.
+// The receipt is written asynchronously.
+// Orders with a tab id are put on that tab, which has to be open.
+// A voucher code of the order is redeemed on one unit of an eligible drink.
+// With a serving policy the order is checked against the limits of its customer, rejections are recorded
+// although the order is not stored.
+func (db *DatabaseHandler) AddOrder(order *model.Order, engine *pricing.Engine, policy *serving.Policy) (*model.Order, error) {
+	if len(order.Items) == 0 {
+		return nil, ErrEmptyOrder
+	}
+	order.Status = model.OrderStatusPlaced
+	order.ReceiptStatus = model.ReceiptPending
+	order.StatusHistory = []model.OrderStatusChange{{ToStatus: model.OrderStatusPlaced}}
+	order.VoucherCode = normalizeVoucherCode(order.VoucherCode)
+	order.ServingFlagged = false
+	now := time.Now()
+	var decision *model.ServingDecision
+	err := db.dbConn.Transaction(func(tx *gorm.DB) error {
+		var rules []model.PricingRule
+		err := tx.Find(&rules).Error
+		if err != nil {
+			return err
+		}
+		for i := range order.Items {
+			var drink model.Drink
+			err := tx.Where("id = ?", order.Items[i].DrinkID).First(&drink).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUnknownDrink
+			}
+			if err != nil {
+				return err
+			}
+			if i > 0 && drink.Price.Currency != order.Items[0].UnitPrice.Currency {
+				return ErrMixedCurrencies
+			}
+			price, rule := engine.Price(&drink, rules, now)
+			order.Items[i].UnitPrice = price
+			order.Items[i].ListPrice = drink.Price
+			order.Items[i].StandardDrinks = drink.StandardDrinks
+			order.Items[i].PricingRuleID = nil
+			order.Items[i].PricingRuleName = ""
+			order.Items[i].VoucherID = nil
+			order.Items[i].VoucherDiscount = money.New(0, price.Currency)
+			if rule != nil {
+				order.Items[i].PricingRuleID = &rule.ID
+				order.Items[i].PricingRuleName = rule.Name
+			}
+		}
+		// quantities of absurdly expensive drinks must not overflow the total
+		_, err = order.Total()
+		if err != nil {
+			return err
+		}
+		if policy != nil && order.CreatedBy != "" {
+			decision, err = evaluateServingPolicy(tx, policy, order, now)
+			if err != nil {
+				return err
+			}
+		}
+		err = reserveStock(tx, order.Items)
+		if err != nil {
+			return err
+		}
+		if order.VoucherCode != "" {
+			err = redeemVoucher(tx, order, now)
+			if err != nil {
+				return err
+			}
+		}
+		if order.TabID != nil {
+			_, err := lockOpenTab(tx, *order.TabID, "SHARE")
+			if err != nil {
+				return err
+			}
+			err = checkTabCurrency(tx, *order.TabID, order.Items[0].UnitPrice.Currency)
+			if err != nil {
+				return err
+			}
+		}
+		err = tx.Omit("Items.Drink").Create(order).Error
+		if err != nil {
+			return err
+		}
+		if decision != nil {
+			decision.OrderID = &order.ID
+			err = tx.Create(decision).Error
+			if err != nil {
+				return err
+			}
+		}
+		err = recordOrderEvent(tx, model.OrderCreated, order.ID)
+		if err != nil {
+			return err
+		}
+		return enqueueReceipt(tx, order.ID)
+	})
+	if errors.Is(err, ErrServingLimitExceeded) && decision != nil {
+		// the order has been rolled back, the rejection is kept for the bar staff
+		recordErr := db.dbConn.Create(decision).Error
+		if recordErr != nil {
+			slog.Error("Unable to record serving decision", slog.String("error", recordErr.Error()))
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
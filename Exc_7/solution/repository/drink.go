@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"errors"
+	"ordersystem/model"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidDrink   = errors.New("drink needs a name, a price that is not negative and in a known currency and standard drinks that are not negative")
+	ErrDrinkNameTaken = errors.New("a drink with this name already exists")
+)
+
+// AddDrink adds a new drink to the menu
+func (db *DatabaseHandler) AddDrink(drink *model.Drink) (*model.Drink, error) {
+	err := validateDrink(drink)
+	if err != nil {
+		return nil, err
+	}
+	// never trust client supplied ids and timestamps
+	drink.Base = model.Base{}
+	err = db.dbConn.Transaction(func(tx *gorm.DB) error {
+		err := checkDrinkName(tx, drink.Name, 0)
+		if err != nil {
+			return err
+		}
+		return tx.Create(drink).Error
+	})
+	if err != nil {
+		return nil, translateDrinkError(err)
+	}
+	return drink, nil
+}
+
+// UpdateDrink replaces name, price, description, category, standard drinks and low stock threshold of an existing drink.
+// Already placed orders keep the price they were ordered at. The stock is only changed by orders and RestockDrink.
+func (db *DatabaseHandler) UpdateDrink(id uint, drink *model.Drink) (*model.Drink, error) {
+	err := validateDrink(drink)
+	if err != nil {
+		return nil, err
+	}
+	var dbDrink model.Drink
+	err = db.dbConn.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("id = ?", id).First(&dbDrink).Error
+		if err != nil {
+			return err
+		}
+		err = checkDrinkName(tx, drink.Name, id)
+		if err != nil {
+			return err
+		}
+		dbDrink.Name = drink.Name
+		dbDrink.Price = drink.Price
+		dbDrink.Description = drink.Description
+		dbDrink.Category = drink.Category
+		dbDrink.StandardDrinks = drink.StandardDrinks
+		dbDrink.LowStockThreshold = drink.LowStockThreshold
+		return tx.Save(&dbDrink).Error
+	})
+	if err != nil {
+		return nil, translateDrinkError(err)
+	}
+	return &dbDrink, nil
+}
+
+// RetireDrink removes a drink from the menu. Drinks that have already been ordered are only
+// soft deleted, so order history and totals stay intact. Returns whether the drink was retired
+// (soft deleted) instead of deleted.
+func (db *DatabaseHandler) RetireDrink(id uint) (retired bool, err error) {
+	err = db.dbConn.Transaction(func(tx *gorm.DB) error {
+		var drink model.Drink
+		err := tx.Where("id = ?", id).First(&drink).Error
+		if err != nil {
+			return err
+		}
+		var ordered bool
+		err = tx.Model(&model.OrderItem{}).
+			Select("count(*) > 0").
+			Where("drink_id = ?", id).
+			Find(&ordered).Error
+		if err != nil {
+			return err
+		}
+		retired = ordered
+		if ordered {
+			return tx.Delete(&drink).Error
+		}
+		return tx.Unscoped().Delete(&drink).Error
+	})
+	return retired, err
+}
+
+func validateDrink(drink *model.Drink) error {
+	if drink.Name == "" || drink.Price.IsNegative() || !drink.Price.IsValid() || drink.StandardDrinks < 0 {
+		return ErrInvalidDrink
+	}
+	return nil
+}
+
+// checkDrinkName makes sure no other drink, including retired ones, uses the name
+func checkDrinkName(tx *gorm.DB, name string, ownId uint) error {
+	var taken bool
+	err := tx.Unscoped().
+		Model(&model.Drink{}).
+		Select("count(*) > 0").
+		Where("name = ? AND id <> ?", name, ownId).
+		Find(&taken).Error
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrDrinkNameTaken
+	}
+	return nil
+}
+
+// translateDrinkError maps a unique violation that slipped past checkDrinkName, i.e. caused by
+// a concurrent request, to ErrDrinkNameTaken
+func translateDrinkError(err error) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrDrinkNameTaken
+	}
+	return err
+}
+
+// GetExistingDrinkIDs reports which of the drinks are on the menu, retired drinks are not
+func (db *DatabaseHandler) GetExistingDrinkIDs(ids []uint) (map[uint]bool, error) {
+	existing := map[uint]bool{}
+	if len(ids) == 0 {
+		return existing, nil
+	}
+	var found []uint
+	err := db.dbConn.Model(&model.Drink{}).Where("id IN ?", ids).Pluck("id", &found).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range found {
+		existing[id] = true
+	}
+	return existing, nil
+}
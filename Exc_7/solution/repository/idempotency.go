@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"crypto/rand"
+	"ordersystem/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ClaimIdempotencyKey tries to reserve the key of the principal for a new request. If the key is already taken,
+// claimed is false and the stored record of the first request is returned. Expired keys are removed and keys
+// of the same request whose lease has passed without completing are taken over, so they can be claimed again.
+// The returned record of a claimed key carries the claim that completes or releases it.
+func (db *DatabaseHandler) ClaimIdempotencyKey(principal string, key string, requestHash string, retention time.Duration, lease time.Duration) (record *model.IdempotencyKey, claimed bool, err error) {
+	now := time.Now()
+	record = &model.IdempotencyKey{
+		Principal:   principal,
+		Key:         key,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(retention),
+		LockedUntil: now.Add(lease),
+		Claim:       rand.Text(),
+		RequestHash: requestHash,
+		Status:      model.IdempotencyInProgress,
+	}
+	err = db.dbConn.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("expires_at < ?", now).Delete(&model.IdempotencyKey{}).Error
+		if err != nil {
+			return err
+		}
+		// the primary key guarantees that only one concurrent request wins
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		if result.Error != nil {
+			return result.Error
+		}
+		claimed = result.RowsAffected == 1
+		if claimed {
+			return nil
+		}
+		// the request holding the key is gone, only one retry wins the update
+		result = tx.Model(&model.IdempotencyKey{}).
+			Where("principal = ? AND key = ? AND request_hash = ? AND status = ? AND locked_until < ?",
+				principal, key, requestHash, model.IdempotencyInProgress, now).
+			Updates(map[string]any{
+				"created_at":   record.CreatedAt,
+				"expires_at":   record.ExpiresAt,
+				"locked_until": record.LockedUntil,
+				"claim":        record.Claim,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		claimed = result.RowsAffected == 1
+		if claimed {
+			return nil
+		}
+		record = &model.IdempotencyKey{}
+		return tx.Where("principal = ? AND key = ?", principal, key).First(record).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return record, claimed, nil
+}
+
+// GetIdempotencyKey loads the record of a claimed key
+func (db *DatabaseHandler) GetIdempotencyKey(principal string, key string) (record *model.IdempotencyKey, err error) {
+	err = db.dbConn.Where("principal = ? AND key = ?", principal, key).First(&record).Error
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// CompleteIdempotencyKey stores the response of the request that claimed the key, unless another request took it over
+func (db *DatabaseHandler) CompleteIdempotencyKey(record *model.IdempotencyKey, status int, contentType string, body []byte) error {
+	return db.dbConn.Model(&model.IdempotencyKey{}).
+		Where("principal = ? AND key = ? AND claim = ?", record.Principal, record.Key, record.Claim).
+		Updates(map[string]any{
+			"status":                model.IdempotencyCompleted,
+			"response_status":       status,
+			"response_content_type": contentType,
+			"response_body":         body,
+		}).Error
+}
+
+// ReleaseIdempotencyKey removes the key, so the request can be retried. Keys taken over by another request are kept.
+func (db *DatabaseHandler) ReleaseIdempotencyKey(record *model.IdempotencyKey) error {
+	return db.dbConn.
+		Where("principal = ? AND key = ? AND claim = ?", record.Principal, record.Key, record.Claim).
+		Delete(&model.IdempotencyKey{}).Error
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"fmt"
+	"log/slog"
+	"ordersystem/model"
+	"ordersystem/money"
+
+	"gorm.io/gorm"
+)
+
+// migrateOrderItemsStmt charges single drink orders the current price of the drink, there were no discounts yet
+const migrateOrderItemsStmt = `INSERT INTO order_items (created_at, updated_at, deleted_at, quantity,
+	unit_price_amount, unit_price_currency, list_price_amount, list_price_currency, standard_drinks, order_id, drink_id)
+SELECT orders.created_at, orders.updated_at, orders.deleted_at, orders.amount,
+	drinks.price_amount, drinks.price_currency, drinks.price_amount, drinks.price_currency, drinks.standard_drinks,
+	orders.id, orders.drink_id
+FROM orders JOIN drinks ON drinks.id = orders.drink_id;`
+
+const migrateFloatPriceStmt = `UPDATE %s SET %s_amount = ROUND(%s * ?), %s_currency = ?;`
+
+// floatPriceColumns lists old float price columns by table, they are replaced by money columns with the same prefix
+var floatPriceColumns = map[string]string{
+	"drinks": "price",
+}
+
+// migrate creates the tables and migrates the data of older versions
+func migrate(dbConn *gorm.DB) error {
+	err := dbConn.AutoMigrate(&model.Drink{}, &model.Order{}, &model.OrderItem{}, &model.OrderStatusChange{}, &model.IdempotencyKey{},
+		&model.OutboxMessage{}, &model.OrderEvent{}, &model.User{},
+		&model.Tab{}, &model.TabShare{}, &model.PricingRule{}, &model.VoucherBatch{}, &model.Voucher{},
+		&model.ServingDecision{}, &model.RateLimitBucket{})
+	if err != nil {
+		return err
+	}
+	err = migrateFloatPrices(dbConn)
+	if err != nil {
+		return err
+	}
+	return migrateSingleDrinkOrders(dbConn)
+}
+
+// migrateSingleDrinkOrders moves the drink_id and amount columns of old single drink orders
+// into order_items and drops the old columns afterwards.
+func migrateSingleDrinkOrders(dbConn *gorm.DB) error {
+	if !dbConn.Migrator().HasColumn("orders", "drink_id") {
+		return nil
+	}
+	slog.Info("Migrating single drink orders to order items")
+	return dbConn.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(migrateOrderItemsStmt).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Migrator().DropColumn("orders", "drink_id")
+		if err != nil {
+			return err
+		}
+		return tx.Migrator().DropColumn("orders", "amount")
+	})
+}
+
+// migrateFloatPrices converts old float price columns into exact money columns in the default currency
+func migrateFloatPrices(dbConn *gorm.DB) error {
+	factor, err := money.MinorUnitFactor(money.DefaultCurrency)
+	if err != nil {
+		return err
+	}
+	return dbConn.Transaction(func(tx *gorm.DB) error {
+		for table, column := range floatPriceColumns {
+			if !tx.Migrator().HasColumn(table, column) {
+				continue
+			}
+			slog.Info("Migrating float prices to money", slog.String("table", table), slog.String("column", column))
+			stmt := fmt.Sprintf(migrateFloatPriceStmt, table, column, column, column)
+			err := tx.Exec(stmt, factor, money.DefaultCurrency).Error
+			if err != nil {
+				return err
+			}
+			err = tx.Migrator().DropColumn(table, column)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
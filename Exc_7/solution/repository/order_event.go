@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"ordersystem/model"
+
+	"gorm.io/gorm"
+)
+
+// sequenceOrderEventsStmt numbers the committed events that have no sequence yet after the highest sequence
+const sequenceOrderEventsStmt = `UPDATE order_events SET sequence = numbered.sequence
+FROM (SELECT id, (SELECT COALESCE(MAX(sequence), 0) FROM order_events) + ROW_NUMBER() OVER (ORDER BY id) AS sequence
+	FROM order_events WHERE sequence = 0) AS numbered
+WHERE order_events.id = numbered.id;`
+
+// recordOrderEvent stores the current state of the order as event. Call it inside the transaction
+// that changes the order. The event is sequenced by the order stream once it has been committed.
+func recordOrderEvent(tx *gorm.DB, eventType model.OrderEventType, orderID uint) error {
+	var order model.Order
+	err := tx.Unscoped().
+		Preload("Items").
+		Where("id = ?", orderID).
+		First(&order).Error
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&model.OrderEvent{Type: eventType, OrderID: orderID, Payload: payload}).Error
+}
+
+// sequenceOrderEvents hands out the sequence of committed events. Only one replica sequences at a time, the others
+// skip sequencing meanwhile and read what it has sequenced. Order changes never wait for the sequencer.
+// The lock is held until the numbers are committed, so every run continues after the sequences of the previous one.
+func (db *DatabaseHandler) sequenceOrderEvents() error {
+	return db.dbConn.Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		err := tx.Raw("SELECT pg_try_advisory_xact_lock(hashtext(?))", "order_events").Scan(&locked).Error
+		if err != nil || !locked {
+			return err
+		}
+		return tx.Exec(sequenceOrderEventsStmt).Error
+	})
+}
+
+// GetOrderEvents returns up to limit events after the given sequence, optionally only of orders containing the drink
+func (db *DatabaseHandler) GetOrderEvents(afterSequence uint64, drinkID *uint, limit int) (events []model.OrderEvent, err error) {
+	err = db.sequenceOrderEvents()
+	if err != nil {
+		return nil, err
+	}
+	query := db.dbConn.Where("sequence > ?", afterSequence)
+	if drinkID != nil {
+		query = query.Where("payload->'items' @> ?::jsonb", fmt.Sprintf(`[{"drink_id": %d}]`, *drinkID))
+	}
+	err = query.Order("sequence").Limit(limit).Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetLastOrderEventSequence returns the sequence of the latest event, or 0 if there is none
+func (db *DatabaseHandler) GetLastOrderEventSequence() (sequence uint64, err error) {
+	err = db.sequenceOrderEvents()
+	if err != nil {
+		return 0, err
+	}
+	err = db.dbConn.Model(&model.OrderEvent{}).
+		Select("COALESCE(MAX(sequence), 0)").
+		Scan(&sequence).Error
+	return sequence, err
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"ordersystem/model"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// orderAmountExpr is the total quantity of all items of an order
+const orderAmountExpr = `(SELECT COALESCE(SUM(order_items.quantity), 0) FROM order_items
+WHERE order_items.order_id = orders.id AND order_items.deleted_at IS NULL)`
+
+const orderHasDrinkExpr = `EXISTS (SELECT 1 FROM order_items
+WHERE order_items.order_id = orders.id AND order_items.drink_id = ? AND order_items.deleted_at IS NULL)`
+
+var ErrUnknownSort = errors.New("unknown sort, use one of id, created_at, amount with optional '-' prefix")
+
+// OrderSort is a sort field with an optional '-' prefix for descending order, i.e. -created_at
+type OrderSort string
+
+var orderSortExprs = map[string]string{
+	"id":         "orders.id",
+	"created_at": "orders.created_at",
+	"amount":     orderAmountExpr,
+}
+
+// ParseOrderSort validates the sort, an empty sort defaults to id ascending
+func ParseOrderSort(sort string) (OrderSort, error) {
+	if sort == "" {
+		return "id", nil
+	}
+	_, ok := orderSortExprs[strings.TrimPrefix(sort, "-")]
+	if !ok {
+		return "", ErrUnknownSort
+	}
+	return OrderSort(sort), nil
+}
+
+func (s OrderSort) field() string {
+	return strings.TrimPrefix(string(s), "-")
+}
+
+func (s OrderSort) descending() bool {
+	return strings.HasPrefix(string(s), "-")
+}
+
+// OrderCursor is the position of the last order of a page. Orders are always sorted by the
+// sort field first and their id second, so the position is unique. The position is only valid for the
+// sort the cursor was issued for.
+type OrderCursor struct {
+	Sort      OrderSort `json:"sort"`
+	ID        uint      `json:"id"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	Amount    uint64    `json:"amount,omitempty"`
+}
+
+// OrderQuery filters, sorts and paginates GetOrders. Nil filters are ignored.
+type OrderQuery struct {
+	Statuses    []model.OrderStatus
+	DrinkID     *uint
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	MinAmount   *uint
+	MaxAmount   *uint
+	Sort        OrderSort
+	Limit       int
+	After       *OrderCursor
+}
+
+// GetOrders returns one page of orders matching the query, the total number of matching orders
+// and the cursor of the next page, which is nil on the last page
+func (db *DatabaseHandler) GetOrders(query OrderQuery) (orders []model.Order, total int64, next *OrderCursor, err error) {
+	if query.Sort == "" {
+		query.Sort = "id"
+	}
+	filtered := db.dbConn.Model(&model.Order{})
+	// cancelled orders are soft deleted
+	if slices.Contains(query.Statuses, model.OrderStatusCancelled) {
+		filtered = filtered.Unscoped()
+	}
+	if len(query.Statuses) > 0 {
+		filtered = filtered.Where("orders.status IN ?", query.Statuses)
+	}
+	if query.DrinkID != nil {
+		filtered = filtered.Where(orderHasDrinkExpr, *query.DrinkID)
+	}
+	if query.CreatedFrom != nil {
+		filtered = filtered.Where("orders.created_at >= ?", *query.CreatedFrom)
+	}
+	if query.CreatedTo != nil {
+		filtered = filtered.Where("orders.created_at < ?", *query.CreatedTo)
+	}
+	if query.MinAmount != nil {
+		filtered = filtered.Where(orderAmountExpr+" >= ?", *query.MinAmount)
+	}
+	if query.MaxAmount != nil {
+		filtered = filtered.Where(orderAmountExpr+" <= ?", *query.MaxAmount)
+	}
+	err = filtered.Session(&gorm.Session{}).Count(&total).Error
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	sortExpr := orderSortExprs[query.Sort.field()]
+	direction, comparison := "ASC", ">"
+	if query.Sort.descending() {
+		direction, comparison = "DESC", "<"
+	}
+	page := filtered.Session(&gorm.Session{}).
+		Preload("Items").
+		Order(fmt.Sprintf("%s %s, orders.id %s", sortExpr, direction, direction))
+	if query.After != nil {
+		page = page.Where(fmt.Sprintf("(%s, orders.id) %s (?, ?)", sortExpr, comparison),
+			query.After.sortValue(query.Sort), query.After.ID)
+	}
+	if query.Limit > 0 {
+		// fetch one more to know if there is a next page
+		page = page.Limit(query.Limit + 1)
+	}
+	err = page.Find(&orders).Error
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	if query.Limit > 0 && len(orders) > query.Limit {
+		orders = orders[:query.Limit]
+		last := orders[len(orders)-1]
+		next = &OrderCursor{Sort: query.Sort, ID: last.ID, CreatedAt: last.CreatedAt, Amount: last.TotalQuantity()}
+	}
+	return orders, total, next, nil
+}
+
+func (c *OrderCursor) sortValue(sort OrderSort) any {
+	switch sort.field() {
+	case "created_at":
+		return c.CreatedAt
+	case "amount":
+		return c.Amount
+	default:
+		return c.ID
+	}
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"errors"
+	"ordersystem/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UpdateOrderStatus moves the order to the given status and records the change in the status history.
+// Moving an order to cancelled is the same as calling CancelOrder without a reason.
+func (db *DatabaseHandler) UpdateOrderStatus(id uint, status model.OrderStatus) (*model.Order, error) {
+	if status == model.OrderStatusCancelled {
+		return db.CancelOrder(id, "")
+	}
+	err := db.dbConn.Transaction(func(tx *gorm.DB) error {
+		order, err := lockOrder(tx, id)
+		if err != nil {
+			return err
+		}
+		return transitionOrder(tx, order, status, map[string]any{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return db.loadOrder(id)
+}
+
+// CancelOrder cancels the order and soft deletes it, so it no longer shows up in listings and totals.
+// The stock of drinks that have not been served yet and redeemed vouchers are given back.
+func (db *DatabaseHandler) CancelOrder(id uint, reason string) (*model.Order, error) {
+	err := db.dbConn.Transaction(func(tx *gorm.DB) error {
+		order, err := lockOrder(tx, id)
+		if err != nil {
+			return err
+		}
+		if order.Status.IsUnserved() && order.Status.CanTransitionTo(model.OrderStatusCancelled) {
+			err = releaseStock(tx, order.ID)
+			if err != nil {
+				return err
+			}
+		}
+		err = releaseVouchers(tx, order.ID)
+		if err != nil {
+			return err
+		}
+		return transitionOrder(tx, order, model.OrderStatusCancelled, map[string]any{
+			"cancellation_reason": reason,
+			"deleted_at":          time.Now(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return db.loadOrder(id)
+}
+
+// RestoreOrder undoes a cancellation and puts the order back into the status it had before.
+// Unserved orders take their stock again, vouchers are redeemed again. This deliberately bypasses the state machine and is meant for admins only.
+func (db *DatabaseHandler) RestoreOrder(id uint) (*model.Order, error) {
+	err := db.dbConn.Transaction(func(tx *gorm.DB) error {
+		var order model.Order
+		err := tx.Unscoped().
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", id).
+			First(&order).Error
+		if err != nil {
+			return err
+		}
+		if !order.DeletedAt.Valid {
+			return ErrOrderNotCancelled
+		}
+		// find status before cancellation
+		var lastChange model.OrderStatusChange
+		err = tx.Where("order_id = ? AND to_status = ?", id, model.OrderStatusCancelled).
+			Order("id DESC").
+			First(&lastChange).Error
+		if err != nil {
+			return err
+		}
+		if lastChange.FromStatus.IsUnserved() {
+			var items []model.OrderItem
+			err = tx.Where("order_id = ?", id).Find(&items).Error
+			if err != nil {
+				return err
+			}
+			err = reserveStock(tx, items)
+			if err != nil {
+				return err
+			}
+		}
+		err = retakeVouchers(tx, order.ID)
+		if err != nil {
+			return err
+		}
+		err = applyTransition(tx, &order, lastChange.FromStatus, map[string]any{
+			"cancellation_reason": "",
+			"deleted_at":          nil,
+		})
+		if err != nil {
+			return err
+		}
+		// replace the void receipt
+		return enqueueReceipt(tx, order.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return db.loadOrder(id)
+}
+
+// lockOrder loads an active order and locks its row until the transaction ends,
+// so concurrent status changes are serialized
+func lockOrder(tx *gorm.DB, id uint) (*model.Order, error) {
+	var order model.Order
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&order).Error
+	if err != nil {
+		// distinguish cancelled from missing orders
+		if errors.Is(err, gorm.ErrRecordNotFound) &&
+			tx.Unscoped().Where("id = ?", id).First(&model.Order{}).Error == nil {
+			return nil, ErrOrderCancelled
+		}
+		return nil, err
+	}
+	return &order, nil
+}
+
+// transitionOrder checks the state machine before applying the transition
+func transitionOrder(tx *gorm.DB, order *model.Order, status model.OrderStatus, updates map[string]any) error {
+	if !order.Status.CanTransitionTo(status) {
+		return ErrIllegalTransition
+	}
+	return applyTransition(tx, order, status, updates)
+}
+
+// applyTransition stores the new status together with additional column updates and records the change
+// in the status history and the order events. Reaching a final status schedules a new receipt.
+func applyTransition(tx *gorm.DB, order *model.Order, status model.OrderStatus, updates map[string]any) error {
+	change := model.OrderStatusChange{
+		FromStatus: order.Status,
+		ToStatus:   status,
+		OrderID:    order.ID,
+	}
+	err := tx.Create(&change).Error
+	if err != nil {
+		return err
+	}
+	updates["status"] = status
+	err = tx.Unscoped().Model(order).Updates(updates).Error
+	if err != nil {
+		return err
+	}
+	err = recordOrderEvent(tx, model.OrderChanged, order.ID)
+	if err != nil {
+		return err
+	}
+	if status.IsFinal() {
+		return enqueueReceipt(tx, order.ID)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"ordersystem/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrReceiptBusy is returned while the receipt of the same order is written by someone else
+var ErrReceiptBusy = errors.New("receipt of the order is being written by another dispatcher")
+
+// enqueueReceipt schedules (re)writing the receipt of the order. Call it inside the transaction
+// that changes the order, so order and receipt can never diverge.
+func enqueueReceipt(tx *gorm.DB, orderID uint) error {
+	message := model.OutboxMessage{
+		Topic:         model.OutboxTopicReceipt,
+		OrderID:       orderID,
+		NextAttemptAt: time.Now(),
+	}
+	err := tx.Create(&message).Error
+	if err != nil {
+		return err
+	}
+	return tx.Unscoped().
+		Model(&model.Order{}).
+		Where("id = ?", orderID).
+		Update("receipt_status", model.ReceiptPending).Error
+}
+
+// enqueueTabBill schedules writing the consolidated bill of a closed tab. Call it inside the transaction
+// that closes the tab.
+func enqueueTabBill(tx *gorm.DB, tabID uint) error {
+	message := model.OutboxMessage{
+		Topic:         model.OutboxTopicTabBill,
+		TabID:         tabID,
+		NextAttemptAt: time.Now(),
+	}
+	return tx.Create(&message).Error
+}
+
+// ClaimOutboxMessages returns up to limit messages that are due. Claimed messages are leased,
+// i.e. hidden from other dispatchers, until lease has passed. Rows locked by other replicas are skipped.
+func (db *DatabaseHandler) ClaimOutboxMessages(limit int, lease time.Duration) (messages []model.OutboxMessage, err error) {
+	err = db.dbConn.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("processed_at IS NULL AND failed_at IS NULL AND next_attempt_at <= ?", now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&messages).Error
+		if err != nil || len(messages) == 0 {
+			return err
+		}
+		ids := make([]uint, len(messages))
+		for i, message := range messages {
+			ids[i] = message.ID
+		}
+		return tx.Model(&model.OutboxMessage{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// CompleteOutboxMessage marks the message as processed. The receipt of the order counts as written
+// once no other message of the order is left.
+func (db *DatabaseHandler) CompleteOutboxMessage(message *model.OutboxMessage) error {
+	return db.dbConn.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(message).Update("processed_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+		if message.Topic == model.OutboxTopicTabBill {
+			return updateBillStatus(tx, message.TabID, model.ReceiptWritten)
+		}
+		return tx.Unscoped().
+			Model(&model.Order{}).
+			Where("id = ?", message.OrderID).
+			Where("NOT EXISTS (SELECT 1 FROM outbox_messages WHERE outbox_messages.order_id = orders.id "+
+				"AND outbox_messages.processed_at IS NULL AND outbox_messages.failed_at IS NULL "+
+				"AND outbox_messages.deleted_at IS NULL)").
+			Update("receipt_status", model.ReceiptWritten).Error
+	})
+}
+
+// WriteReceipt loads the order and passes it to write while holding a lock on the receipt of the order.
+// Receipts of the same order are therefore written one after another, each from the order as it is when the
+// lock is taken, so a render of an older state can never overwrite a newer one. Changes to the order are not
+// blocked. ErrReceiptBusy is returned right away if another dispatcher or request holds the lock.
+func (db *DatabaseHandler) WriteReceipt(orderID uint, write func(order *model.Order) error) error {
+	return db.dbConn.Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		err := tx.Raw("SELECT pg_try_advisory_xact_lock(hashtext(?))", fmt.Sprintf("receipt:%d", orderID)).
+			Scan(&locked).Error
+		if err != nil {
+			return err
+		}
+		if !locked {
+			return ErrReceiptBusy
+		}
+		order, err := findOrder(tx, orderID)
+		if err != nil {
+			return err
+		}
+		return write(order)
+	})
+}
+
+// DeferOutboxMessage postpones the message without counting a failed attempt
+func (db *DatabaseHandler) DeferOutboxMessage(message *model.OutboxMessage, nextAttemptAt time.Time) error {
+	return db.dbConn.Model(message).Update("next_attempt_at", nextAttemptAt).Error
+}
+
+// RetryOutboxMessage records a failed attempt and schedules the next one
+func (db *DatabaseHandler) RetryOutboxMessage(message *model.OutboxMessage, cause error, nextAttemptAt time.Time) error {
+	return db.dbConn.Model(message).Updates(map[string]any{
+		"attempts":        gorm.Expr("attempts + 1"),
+		"last_error":      cause.Error(),
+		"next_attempt_at": nextAttemptAt,
+	}).Error
+}
+
+// FailOutboxMessage gives up on the message and marks the receipt of the order or the bill of the tab as failed
+func (db *DatabaseHandler) FailOutboxMessage(message *model.OutboxMessage, cause error) error {
+	return db.dbConn.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(message).Updates(map[string]any{
+			"attempts":   gorm.Expr("attempts + 1"),
+			"last_error": cause.Error(),
+			"failed_at":  time.Now(),
+		}).Error
+		if err != nil {
+			return err
+		}
+		if message.Topic == model.OutboxTopicTabBill {
+			return updateBillStatus(tx, message.TabID, model.ReceiptFailed)
+		}
+		return tx.Unscoped().
+			Model(&model.Order{}).
+			Where("id = ?", message.OrderID).
+			Update("receipt_status", model.ReceiptFailed).Error
+	})
+}
+
+func updateBillStatus(tx *gorm.DB, tabID uint, status model.ReceiptStatus) error {
+	return tx.Model(&model.Tab{}).
+		Where("id = ?", tabID).
+		Update("bill_status", status).Error
+}
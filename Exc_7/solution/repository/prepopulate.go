@@ -5,14 +5,15 @@ import (
 	"log/slog"
 	"math/rand"
 	"ordersystem/model"
+	"ordersystem/money"
+	"ordersystem/receipt"
 	"ordersystem/storage"
-	"strings"
 	"time"
 
 	"github.com/minio/minio-go/v7"
 )
 
-func Prepopulate(db *DatabaseHandler, s3 *minio.Client) error {
+func Prepopulate(db *DatabaseHandler, s3 *minio.Client, renderer *receipt.Renderer) error {
 	// check if prepopulate has already run once
 	var exists bool
 	err := db.dbConn.Model(&model.Drink{}).
@@ -29,38 +30,47 @@ func Prepopulate(db *DatabaseHandler, s3 *minio.Client) error {
 	slog.Info("Prepopulating database and S3")
 	// create drink menu
 	drinks := []model.Drink{
-		{Name: "Beer", Price: 2.0, Description: "Hagenberger Gold"},
-		{Name: "Spritzer", Price: 1.4, Description: "Wine with soda"},
-		{Name: "Coffee", Price: 0.0, Description: "Mifare isn't that secure ;)"},
+		{Name: "Beer", Price: money.New(200, money.DefaultCurrency), Description: "Hagenberger Gold", Category: "beer",
+			StandardDrinks: 2, Stock: stock(200), LowStockThreshold: 20},
+		{Name: "Spritzer", Price: money.New(140, money.DefaultCurrency), Description: "Wine with soda", Category: "wine",
+			StandardDrinks: 1, Stock: stock(100), LowStockThreshold: 10},
+		// coffee never runs out and is exempt from the serving policy
+		{Name: "Coffee", Price: money.New(0, money.DefaultCurrency), Description: "Mifare isn't that secure ;)",
+			Category: "hot drinks"},
 	}
 	err = db.dbConn.Create(drinks).Error
 	if err != nil {
 		return err
 	}
-	// create orders
+	// create orders with one to three random drinks each
 	var orders []model.Order
-	for _, drink := range drinks {
-		for i := 0; i < 15; i++ {
-			order := model.Order{
-				Base: model.Base{
-					CreatedAt: time.Now().Add(time.Duration(rand.Intn(30)) * time.Minute),
-				},
-				Amount:  uint64(rand.Intn(5)),
-				DrinkID: drink.ID,
-			}
-			orders = append(orders, order)
+	for i := 0; i < 45; i++ {
+		order := model.Order{
+			Base: model.Base{
+				CreatedAt: time.Now().Add(time.Duration(rand.Intn(30)) * time.Minute),
+			},
+			Status:        model.OrderStatusPlaced,
+			StatusHistory: []model.OrderStatusChange{{ToStatus: model.OrderStatusPlaced}},
 		}
+		for _, idx := range rand.Perm(len(drinks))[:rand.Intn(len(drinks))+1] {
+			drink := drinks[idx]
+			order.Items = append(order.Items, model.OrderItem{
+				Quantity:  uint64(rand.Intn(5) + 1),
+				UnitPrice: drink.Price,
+				ListPrice: drink.Price,
+				DrinkID:   drink.ID,
+				Drink:     drink,
+			})
+		}
+		orders = append(orders, order)
 	}
-	err = db.dbConn.Create(orders).Error
+	err = db.dbConn.Omit("Items.Drink").Create(orders).Error
 	if err != nil {
 		return err
 	}
 	// store orders to s3
 	for _, order := range orders {
-		markdown := order.ToMarkdown()
-		receiptReader := strings.NewReader(markdown)
-		_, err = s3.PutObject(context.Background(), storage.OrdersBucket, order.GetFilename(), receiptReader, int64(len(markdown)),
-			minio.PutObjectOptions{ContentType: "text/markdown"})
+		err = storage.PutReceipt(context.Background(), s3, renderer, &order)
 		if err != nil {
 			return err
 		}
@@ -68,3 +78,7 @@ func Prepopulate(db *DatabaseHandler, s3 *minio.Client) error {
 
 	return nil
 }
+
+func stock(servings uint64) *uint64 {
+	return &servings
+}
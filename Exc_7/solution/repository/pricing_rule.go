@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"ordersystem/model"
+	"ordersystem/pricing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AddPricingRule stores a new pricing rule, it applies to orders placed from now on
+func (db *DatabaseHandler) AddPricingRule(rule *model.PricingRule) (*model.PricingRule, error) {
+	err := pricing.Validate(rule)
+	if err != nil {
+		return nil, err
+	}
+	// never trust client supplied ids and timestamps
+	rule.Base = model.Base{}
+	err = db.dbConn.Create(rule).Error
+	if err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// GetPricingRules returns all pricing rules, including those outside their time window
+func (db *DatabaseHandler) GetPricingRules() (rules []model.PricingRule, err error) {
+	err = db.dbConn.Order("id").Find(&rules).Error
+	if err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// DeletePricingRule removes the rule. It is only soft deleted, so orders keep referencing it.
+func (db *DatabaseHandler) DeletePricingRule(id uint) error {
+	result := db.dbConn.Delete(&model.PricingRule{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetMenu returns all drinks with their effective price according to the pricing rules
+func (db *DatabaseHandler) GetMenu(engine *pricing.Engine) ([]model.Drink, error) {
+	drinks, err := db.GetDrinks()
+	if err != nil {
+		return nil, err
+	}
+	rules, err := db.GetPricingRules()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range drinks {
+		price, rule := engine.Price(&drinks[i], rules, now)
+		drinks[i].EffectivePrice = &price
+		if rule != nil {
+			drinks[i].PricingRule = rule.Name
+		}
+	}
+	return drinks, nil
+}
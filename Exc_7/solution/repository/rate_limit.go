@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"ordersystem/model"
+	"ordersystem/ratelimit"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TakeRateLimitToken takes a token from the bucket shared by all replicas. The bucket row is locked,
+// so concurrent requests on any replica take their tokens one after another.
+func (db *DatabaseHandler) TakeRateLimitToken(ctx context.Context, key string, limit ratelimit.Limit, now time.Time) (result ratelimit.Result, err error) {
+	err = db.dbConn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&model.RateLimitBucket{Key: key, Tokens: float64(limit.Requests), RefilledAt: now}).Error
+		if err != nil {
+			return err
+		}
+		var dbBucket model.RateLimitBucket
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("key = ?", key).
+			First(&dbBucket).Error
+		if err != nil {
+			return err
+		}
+		var bucket ratelimit.Bucket
+		bucket, result = limit.Take(ratelimit.Bucket{Tokens: dbBucket.Tokens, RefilledAt: dbBucket.RefilledAt}, now)
+		return tx.Model(&dbBucket).Updates(map[string]any{
+			"tokens":      bucket.Tokens,
+			"refilled_at": bucket.RefilledAt,
+		}).Error
+	})
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+	return result, nil
+}
+
+// PeekRateLimitToken reports whether a token could be taken from the shared bucket, the bucket is left as it is.
+// Buckets that do not exist yet are full.
+func (db *DatabaseHandler) PeekRateLimitToken(ctx context.Context, key string, limit ratelimit.Limit, now time.Time) (ratelimit.Result, error) {
+	var dbBuckets []model.RateLimitBucket
+	err := db.dbConn.WithContext(ctx).Where("key = ?", key).Limit(1).Find(&dbBuckets).Error
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+	var bucket ratelimit.Bucket
+	if len(dbBuckets) > 0 {
+		bucket = ratelimit.Bucket{Tokens: dbBuckets[0].Tokens, RefilledAt: dbBuckets[0].RefilledAt}
+	}
+	_, result := limit.Take(bucket, now)
+	return result, nil
+}
+
+// SweepRateLimitBuckets removes buckets that have not been used since before
+func (db *DatabaseHandler) SweepRateLimitBuckets(ctx context.Context, before time.Time) error {
+	return db.dbConn.WithContext(ctx).Where("refilled_at < ?", before).Delete(&model.RateLimitBucket{}).Error
+}
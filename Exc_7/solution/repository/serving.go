@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"ordersystem/model"
+	"ordersystem/serving"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const maxServingDecisions = 500
+
+var ErrServingLimitExceeded = errors.New("serving limit exceeded")
+
+const consumptionStmt = `SELECT orders.created_at,
+	SUM(order_items.quantity * order_items.standard_drinks) AS standard_drinks
+FROM orders JOIN order_items ON order_items.order_id = orders.id
+WHERE orders.deleted_at IS NULL AND order_items.deleted_at IS NULL
+	AND orders.created_by = @customer AND orders.created_at > @since
+GROUP BY orders.id, orders.created_at;`
+
+// evaluateServingPolicy checks the order against the serving policy before it is stored. Orders of the same customer
+// are serialized with an advisory lock, so concurrent orders cannot slip past the limits together.
+// Flagged orders are marked, rejected ones return ErrServingLimitExceeded. The decision is returned in both cases.
+func evaluateServingPolicy(tx *gorm.DB, policy *serving.Policy, order *model.Order, now time.Time) (*model.ServingDecision, error) {
+	var ordered float64
+	for _, item := range order.Items {
+		ordered += float64(item.Quantity) * item.StandardDrinks
+	}
+	if ordered <= 0 {
+		// non-alcoholic orders are exempt
+		return nil, nil
+	}
+	err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "serving:"+order.CreatedBy).Error
+	if err != nil {
+		return nil, err
+	}
+	var history []serving.Consumption
+	err = tx.Raw(consumptionStmt, sql.Named("customer", order.CreatedBy), sql.Named("since", now.Add(-policy.Window()))).
+		Scan(&history).Error
+	if err != nil {
+		return nil, err
+	}
+	decision := policy.Evaluate(history, ordered, now)
+	decision.Customer = order.CreatedBy
+	switch decision.Action {
+	case model.ServingReject:
+		return &decision, fmt.Errorf("%w: %.1f standard drinks ordered within %s, the limit is %.1f",
+			ErrServingLimitExceeded, decision.Consumed+decision.Ordered, decision.Window, decision.Limit)
+	case model.ServingFlag:
+		order.ServingFlagged = true
+		return &decision, nil
+	default:
+		return nil, nil
+	}
+}
+
+// GetServingDecisions returns the latest flagged and rejected orders, optionally of a single customer or action
+func (db *DatabaseHandler) GetServingDecisions(customer string, action model.ServingAction) (decisions []model.ServingDecision, err error) {
+	query := db.dbConn.Order("id DESC").Limit(maxServingDecisions)
+	if customer != "" {
+		query = query.Where("customer = ?", customer)
+	}
+	if action != "" {
+		query = query.Where("action = ?", action)
+	}
+	err = query.Find(&decisions).Error
+	if err != nil {
+		return nil, err
+	}
+	return decisions, nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"ordersystem/model"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInsufficientStock = errors.New("not enough stock")
+	ErrInvalidRestock    = errors.New("restock quantity must be positive")
+)
+
+// RestockDrink adds the quantity to the stock of the drink. Untracked drinks start tracking their stock.
+func (db *DatabaseHandler) RestockDrink(id uint, quantity uint64) (*model.Drink, error) {
+	if quantity == 0 {
+		return nil, ErrInvalidRestock
+	}
+	result := db.dbConn.Model(&model.Drink{}).
+		Where("id = ?", id).
+		Update("stock", gorm.Expr("COALESCE(stock, 0) + ?", quantity))
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	var drink model.Drink
+	err := db.dbConn.Where("id = ?", id).First(&drink).Error
+	if err != nil {
+		return nil, err
+	}
+	return &drink, nil
+}
+
+// reserveStock takes the ordered quantities from the stock of the drinks. Each drink is decremented
+// with a conditional update, which locks its row, so concurrent orders on any replica cannot oversell.
+// Drinks are updated in id order to avoid deadlocks between concurrent orders.
+func reserveStock(tx *gorm.DB, items []model.OrderItem) error {
+	quantities := orderedQuantities(items)
+	for _, drinkID := range sortedDrinkIDs(quantities) {
+		result := tx.Unscoped().
+			Model(&model.Drink{}).
+			Where("id = ? AND (stock IS NULL OR stock >= ?)", drinkID, quantities[drinkID]).
+			Update("stock", gorm.Expr("stock - ?", quantities[drinkID]))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			var drink model.Drink
+			err := tx.Unscoped().Where("id = ?", drinkID).First(&drink).Error
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("%w: only %d %s left", ErrInsufficientStock, *drink.Stock, drink.Name)
+		}
+	}
+	return nil
+}
+
+// releaseStock gives the quantities of an unserved order back to the stock of the drinks
+func releaseStock(tx *gorm.DB, orderID uint) error {
+	var items []model.OrderItem
+	err := tx.Where("order_id = ?", orderID).Find(&items).Error
+	if err != nil {
+		return err
+	}
+	quantities := orderedQuantities(items)
+	for _, drinkID := range sortedDrinkIDs(quantities) {
+		err = tx.Unscoped().
+			Model(&model.Drink{}).
+			Where("id = ? AND stock IS NOT NULL", drinkID).
+			Update("stock", gorm.Expr("stock + ?", quantities[drinkID])).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// orderedQuantities sums up the quantities per drink, a drink may appear in several items
+func orderedQuantities(items []model.OrderItem) map[uint]uint64 {
+	quantities := map[uint]uint64{}
+	for _, item := range items {
+		quantities[item.DrinkID] += item.Quantity
+	}
+	return quantities
+}
+
+func sortedDrinkIDs(quantities map[uint]uint64) []uint {
+	ids := make([]uint, 0, len(quantities))
+	for id := range quantities {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
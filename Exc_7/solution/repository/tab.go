@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"ordersystem/model"
+	"ordersystem/money"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidTab      = errors.New("tab needs a name")
+	ErrUnknownTab      = errors.New("tab does not exist")
+	ErrTabClosed       = errors.New("tab has been closed")
+	ErrTabUnsettled    = errors.New("all orders on the tab must be served before it can be closed")
+	ErrOrderOnOtherTab = errors.New("order is already on another tab")
+	ErrInvalidSplit    = errors.New("invalid bill split")
+)
+
+// OpenTab opens a new tab on behalf of the principal
+func (db *DatabaseHandler) OpenTab(name string, openedBy string) (*model.Tab, error) {
+	if name == "" {
+		return nil, ErrInvalidTab
+	}
+	tab := &model.Tab{Name: name, Status: model.TabOpen, OpenedBy: openedBy}
+	err := db.dbConn.Create(tab).Error
+	if err != nil {
+		return nil, err
+	}
+	return db.GetTab(tab.ID)
+}
+
+// GetTab returns the tab with all orders that have not been cancelled.
+// The total of open tabs is the running total of their orders.
+// Retired drinks are loaded as well, so their names still show on the bill.
+func (db *DatabaseHandler) GetTab(id uint) (tab *model.Tab, err error) {
+	unscoped := func(tx *gorm.DB) *gorm.DB { return tx.Unscoped() }
+	err = db.dbConn.
+		Preload("Orders", func(tx *gorm.DB) *gorm.DB { return tx.Order("orders.id") }).
+		Preload("Orders.Items.Drink", unscoped).
+		Preload("Shares.Items.Drink", unscoped).
+		Where("id = ?", id).
+		First(&tab).Error
+	if err != nil {
+		return nil, err
+	}
+	if tab.Status == model.TabOpen {
+		tab.Total, err = tab.OrdersTotal()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tab, nil
+}
+
+// AttachOrder puts an existing order on the open tab
+func (db *DatabaseHandler) AttachOrder(tabID uint, orderID uint) (*model.Tab, error) {
+	err := db.dbConn.Transaction(func(tx *gorm.DB) error {
+		_, err := lockOpenTab(tx, tabID, "UPDATE")
+		if err != nil {
+			return err
+		}
+		order, err := lockOrder(tx, orderID)
+		if err != nil {
+			return err
+		}
+		if order.TabID != nil {
+			if *order.TabID == tabID {
+				return nil
+			}
+			return ErrOrderOnOtherTab
+		}
+		var currency string
+		err = tx.Model(&model.OrderItem{}).
+			Where("order_id = ?", orderID).
+			Limit(1).
+			Pluck("unit_price_currency", &currency).Error
+		if err != nil {
+			return err
+		}
+		err = checkTabCurrency(tx, tabID, currency)
+		if err != nil {
+			return err
+		}
+		err = tx.Model(order).Update("tab_id", tabID).Error
+		if err != nil {
+			return err
+		}
+		return recordOrderEvent(tx, model.OrderChanged, order.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return db.GetTab(tabID)
+}
+
+// CloseTab settles the tab: all served orders are marked as paid, the bill is split among the payers
+// and the consolidated bill is written to S3 asynchronously.
+func (db *DatabaseHandler) CloseTab(id uint, closing *model.TabClosing) (*model.Tab, error) {
+	err := db.dbConn.Transaction(func(tx *gorm.DB) error {
+		tab, err := lockOpenTab(tx, id, "UPDATE")
+		if err != nil {
+			return err
+		}
+		// lock all orders of the tab, so none is cancelled or changed while the tab is settled
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Items").
+			Where("tab_id = ?", id).
+			Order("id").
+			Find(&tab.Orders).Error
+		if err != nil {
+			return err
+		}
+		for i := range tab.Orders {
+			order := &tab.Orders[i]
+			switch order.Status {
+			case model.OrderStatusPaid:
+			case model.OrderStatusServed:
+				err = transitionOrder(tx, order, model.OrderStatusPaid, map[string]any{})
+				if err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("%w: order %d is %s", ErrTabUnsettled, order.ID, order.Status)
+			}
+		}
+		tab.Total, err = tab.OrdersTotal()
+		if err != nil {
+			return err
+		}
+		shares, err := splitBill(tab, closing)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		err = tx.Model(tab).Updates(map[string]any{
+			"status":         model.TabClosed,
+			"closed_at":      now,
+			"split_mode":     closing.Split,
+			"total_amount":   tab.Total.Amount,
+			"total_currency": tab.Total.Currency,
+			"bill_status":    model.ReceiptPending,
+		}).Error
+		if err != nil {
+			return err
+		}
+		for i := range shares {
+			shares[i].TabID = tab.ID
+		}
+		if len(shares) > 0 {
+			err = tx.Omit("Items.*").Create(&shares).Error
+			if err != nil {
+				return err
+			}
+		}
+		return enqueueTabBill(tx, tab.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return db.GetTab(id)
+}
+
+// lockOpenTab loads the tab and locks its row until the transaction ends. Closing takes an UPDATE lock,
+// placing orders on the tab a SHARE lock, so no order slips onto a tab while it is being closed.
+func lockOpenTab(tx *gorm.DB, id uint, strength string) (*model.Tab, error) {
+	var tab model.Tab
+	err := tx.Clauses(clause.Locking{Strength: strength}).
+		Where("id = ?", id).
+		First(&tab).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUnknownTab
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tab.Status != model.TabOpen {
+		return nil, ErrTabClosed
+	}
+	return &tab, nil
+}
+
+// checkTabCurrency makes sure all orders on a tab share the same currency, so the tab can be totalled
+func checkTabCurrency(tx *gorm.DB, tabID uint, currency string) error {
+	var currencies []string
+	err := tx.Model(&model.OrderItem{}).
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.tab_id = ? AND orders.deleted_at IS NULL", tabID).
+		Distinct().
+		Pluck("order_items.unit_price_currency", &currencies).Error
+	if err != nil {
+		return err
+	}
+	for _, tabCurrency := range currencies {
+		if tabCurrency != currency {
+			return ErrMixedCurrencies
+		}
+	}
+	return nil
+}
+
+// splitBill divides the total of the tab among the payers
+func splitBill(tab *model.Tab, closing *model.TabClosing) ([]model.TabShare, error) {
+	if len(closing.Payers) == 0 {
+		return nil, fmt.Errorf("%w: at least one payer is needed", ErrInvalidSplit)
+	}
+	for _, payer := range closing.Payers {
+		if payer.Name == "" {
+			return nil, fmt.Errorf("%w: every payer needs a name", ErrInvalidSplit)
+		}
+	}
+	switch closing.Split {
+	case model.SplitEven:
+		return splitEven(tab, closing.Payers)
+	case model.SplitItems:
+		return splitItems(tab, closing.Payers)
+	default:
+		return nil, fmt.Errorf("%w: split must be '%s' or '%s'", ErrInvalidSplit, model.SplitEven, model.SplitItems)
+	}
+}
+
+func splitEven(tab *model.Tab, payers []model.TabPayer) ([]model.TabShare, error) {
+	amounts := tab.Total.Split(len(payers))
+	shares := make([]model.TabShare, len(payers))
+	for i, payer := range payers {
+		if len(payer.ItemIDs) > 0 {
+			return nil, fmt.Errorf("%w: items can only be assigned when splitting by items", ErrInvalidSplit)
+		}
+		shares[i] = model.TabShare{Payer: payer.Name, Amount: amounts[i]}
+	}
+	return shares, nil
+}
+
+// splitItems assigns every line item of the tab to exactly one payer
+func splitItems(tab *model.Tab, payers []model.TabPayer) ([]model.TabShare, error) {
+	items := map[uint]model.OrderItem{}
+	for _, order := range tab.Orders {
+		if order.IsCancelled() {
+			continue
+		}
+		for _, item := range order.Items {
+			items[item.ID] = item
+		}
+	}
+	assigned := map[uint]bool{}
+	shares := make([]model.TabShare, len(payers))
+	for i, payer := range payers {
+		shares[i] = model.TabShare{Payer: payer.Name, Amount: money.New(0, tab.Total.Currency)}
+		for _, itemID := range payer.ItemIDs {
+			item, ok := items[itemID]
+			if !ok {
+				return nil, fmt.Errorf("%w: item %d is not on the tab", ErrInvalidSplit, itemID)
+			}
+			if assigned[itemID] {
+				return nil, fmt.Errorf("%w: item %d is assigned twice", ErrInvalidSplit, itemID)
+			}
+			assigned[itemID] = true
+			lineTotal, err := item.LineTotal()
+			if err != nil {
+				return nil, err
+			}
+			amount, err := shares[i].Amount.Add(lineTotal)
+			if err != nil {
+				return nil, err
+			}
+			shares[i].Amount = amount
+			shares[i].Items = append(shares[i].Items, item)
+		}
+	}
+	for itemID := range items {
+		if !assigned[itemID] {
+			return nil, fmt.Errorf("%w: item %d is not assigned to a payer", ErrInvalidSplit, itemID)
+		}
+	}
+	return shares, nil
+}
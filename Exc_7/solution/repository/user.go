@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"errors"
+	"ordersystem/auth"
+	"ordersystem/model"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidUser   = errors.New("user needs a username without ':' and a known role")
+	ErrUsernameTaken = errors.New("a user with this username already exists")
+)
+
+// AddUser stores a new user with the hashed password
+func (db *DatabaseHandler) AddUser(newUser *model.NewUser) (*model.User, error) {
+	// colons are reserved for api key principals
+	if newUser.Username == "" || strings.Contains(newUser.Username, ":") || !newUser.Role.IsValid() {
+		return nil, ErrInvalidUser
+	}
+	hash, err := auth.HashPassword(newUser.Password)
+	if err != nil {
+		return nil, err
+	}
+	user := &model.User{Username: newUser.Username, Role: newUser.Role, PasswordHash: hash}
+	err = db.dbConn.Create(user).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil, ErrUsernameTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetUserByName returns the user with the given username
+func (db *DatabaseHandler) GetUserByName(username string) (user *model.User, err error) {
+	err = db.dbConn.Where("username = ?", username).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
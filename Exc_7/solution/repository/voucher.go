@@ -0,0 +1,283 @@
+package repository
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"ordersystem/model"
+	"ordersystem/money"
+	"ordersystem/pricing"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	maxVoucherBatchSize = 1000
+	maxVoucherPrefixLen = 12
+	// no 0/O and 1/I, codes are read from paper
+	voucherAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+var (
+	ErrInvalidVoucherBatch  = errors.New("invalid voucher batch")
+	ErrUnknownVoucher       = errors.New("voucher code does not exist")
+	ErrVoucherExpired       = errors.New("voucher has expired")
+	ErrVoucherUsedUp        = errors.New("voucher has already been redeemed")
+	ErrVoucherNotApplicable = errors.New("voucher does not apply to any drink of the order")
+)
+
+// AddVoucherBatch generates a batch of random voucher codes. Without discount the vouchers are good for a free drink.
+func (db *DatabaseHandler) AddVoucherBatch(newBatch *model.NewVoucherBatch) (*model.VoucherBatch, error) {
+	batch := &model.VoucherBatch{
+		Name:           newBatch.Name,
+		DrinkID:        newBatch.DrinkID,
+		MaxRedemptions: newBatch.MaxRedemptions,
+		ExpiresAt:      newBatch.ExpiresAt,
+		DiscountType:   newBatch.DiscountType,
+		Percent:        newBatch.Percent,
+		Discount:       newBatch.Discount.Money(),
+	}
+	if batch.DiscountType == "" {
+		batch.DiscountType = model.DiscountPercent
+		batch.Percent = 100
+	}
+	prefix := strings.ToUpper(newBatch.Prefix)
+	err := validateVoucherBatch(batch, newBatch.Count, prefix)
+	if err != nil {
+		return nil, err
+	}
+	for range newBatch.Count {
+		batch.Vouchers = append(batch.Vouchers, model.Voucher{
+			Code:           generateVoucherCode(prefix),
+			MaxRedemptions: batch.MaxRedemptions,
+		})
+	}
+	err = db.dbConn.Transaction(func(tx *gorm.DB) error {
+		if batch.DrinkID != nil {
+			err := tx.Where("id = ?", *batch.DrinkID).First(&model.Drink{}).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUnknownDrink
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Create(batch).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// GetVoucherBatch returns the batch with all its codes
+func (db *DatabaseHandler) GetVoucherBatch(id uint) (batch *model.VoucherBatch, err error) {
+	err = db.dbConn.
+		Preload("Vouchers", func(tx *gorm.DB) *gorm.DB { return tx.Order("vouchers.id") }).
+		Where("id = ?", id).
+		First(&batch).Error
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+const voucherUsageStmt = `SELECT voucher_batches.id AS batch_id, voucher_batches.name, voucher_batches.drink_id, voucher_batches.expires_at,
+	COUNT(vouchers.id) AS codes,
+	COUNT(vouchers.id) FILTER (WHERE vouchers.redemptions > 0) AS used_codes,
+	COALESCE(SUM(vouchers.max_redemptions), 0) AS max_redemptions,
+	COALESCE(SUM(vouchers.redemptions), 0) AS redemptions
+FROM voucher_batches LEFT JOIN vouchers ON vouchers.batch_id = voucher_batches.id AND vouchers.deleted_at IS NULL
+WHERE voucher_batches.deleted_at IS NULL
+GROUP BY voucher_batches.id
+ORDER BY voucher_batches.id;`
+
+const voucherDiscountStmt = `SELECT vouchers.batch_id,
+	CAST(SUM(order_items.quantity * order_items.voucher_discount_amount) AS bigint) AS discount_amount,
+	order_items.unit_price_currency AS discount_currency
+FROM order_items JOIN orders ON orders.id = order_items.order_id JOIN vouchers ON vouchers.id = order_items.voucher_id
+WHERE orders.deleted_at IS NULL AND order_items.deleted_at IS NULL
+GROUP BY vouchers.batch_id, order_items.unit_price_currency
+ORDER BY vouchers.batch_id, order_items.unit_price_currency;`
+
+// GetVoucherUsage reports per batch how many codes have been redeemed and the discount granted on them.
+// Cancelled orders don't count.
+func (db *DatabaseHandler) GetVoucherUsage() ([]model.VoucherUsage, error) {
+	var usage []model.VoucherUsage
+	err := db.dbConn.Raw(voucherUsageStmt).Scan(&usage).Error
+	if err != nil {
+		return nil, err
+	}
+	var discounts []struct {
+		BatchID  uint
+		Discount money.Money `gorm:"embedded;embeddedPrefix:discount_"`
+	}
+	err = db.dbConn.Raw(voucherDiscountStmt).Scan(&discounts).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range usage {
+		usage[i].Discounts = []money.Money{}
+		for _, discount := range discounts {
+			if discount.BatchID == usage[i].BatchID {
+				usage[i].Discounts = append(usage[i].Discounts, discount.Discount)
+			}
+		}
+	}
+	return usage, nil
+}
+
+// redeemVoucher redeems the voucher code of the order on one unit of an eligible drink, the most expensive one
+// if the voucher applies to any drink. The unit is split off into its own item, so totals, bills and statistics
+// see the discounted price. Each code is counted with a conditional update, which locks its row, so concurrent
+// orders cannot redeem it more often than allowed.
+func redeemVoucher(tx *gorm.DB, order *model.Order, now time.Time) error {
+	var voucher model.Voucher
+	err := tx.Preload("Batch").Where("code = ?", order.VoucherCode).First(&voucher).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrUnknownVoucher
+	}
+	if err != nil {
+		return err
+	}
+	batch := voucher.Batch
+	if batch.IsExpired(now) {
+		return ErrVoucherExpired
+	}
+	index := -1
+	for i, item := range order.Items {
+		if batch.DrinkID != nil && *batch.DrinkID != item.DrinkID {
+			continue
+		}
+		if index < 0 || item.UnitPrice.Amount > order.Items[index].UnitPrice.Amount {
+			index = i
+		}
+	}
+	if index < 0 {
+		return ErrVoucherNotApplicable
+	}
+	price, ok := pricing.ApplyDiscount(order.Items[index].UnitPrice, batch.DiscountType, batch.Percent, batch.Discount)
+	if !ok {
+		return ErrVoucherNotApplicable
+	}
+	err = takeVoucher(tx, voucher.ID)
+	if err != nil {
+		return err
+	}
+	unit := order.Items[index]
+	unit.Quantity = 1
+	unit.UnitPrice = price
+	unit.VoucherID = &voucher.ID
+	// pricing rules may have discounted the unit already, only the rest is the discount of the voucher
+	unit.VoucherDiscount = money.New(order.Items[index].UnitPrice.Amount-price.Amount, price.Currency)
+	if order.Items[index].Quantity == 1 {
+		order.Items[index] = unit
+	} else {
+		order.Items[index].Quantity--
+		order.Items = append(order.Items, unit)
+	}
+	return nil
+}
+
+// takeVoucher counts a redemption of the voucher unless it has been redeemed as often as allowed
+func takeVoucher(tx *gorm.DB, id uint) error {
+	result := tx.Model(&model.Voucher{}).
+		Where("id = ? AND redemptions < max_redemptions", id).
+		Update("redemptions", gorm.Expr("redemptions + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVoucherUsedUp
+	}
+	return nil
+}
+
+// releaseVouchers gives the vouchers redeemed on a cancelled order back
+func releaseVouchers(tx *gorm.DB, orderID uint) error {
+	voucherIDs, err := orderVoucherIDs(tx, orderID)
+	if err != nil {
+		return err
+	}
+	for _, id := range voucherIDs {
+		err = tx.Model(&model.Voucher{}).
+			Where("id = ? AND redemptions > 0", id).
+			Update("redemptions", gorm.Expr("redemptions - 1")).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retakeVouchers redeems the vouchers of a restored order again, they may have been used up in the meantime.
+// Expiry is not checked, the order was placed while the vouchers were valid.
+func retakeVouchers(tx *gorm.DB, orderID uint) error {
+	voucherIDs, err := orderVoucherIDs(tx, orderID)
+	if err != nil {
+		return err
+	}
+	for _, id := range voucherIDs {
+		err = takeVoucher(tx, id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func orderVoucherIDs(tx *gorm.DB, orderID uint) (voucherIDs []uint, err error) {
+	err = tx.Model(&model.OrderItem{}).
+		Where("order_id = ? AND voucher_id IS NOT NULL", orderID).
+		Pluck("voucher_id", &voucherIDs).Error
+	if err != nil {
+		return nil, err
+	}
+	return voucherIDs, nil
+}
+
+func validateVoucherBatch(batch *model.VoucherBatch, count uint64, prefix string) error {
+	if batch.Name == "" {
+		return fmt.Errorf("%w: name is missing", ErrInvalidVoucherBatch)
+	}
+	if count == 0 || count > maxVoucherBatchSize {
+		return fmt.Errorf("%w: count must be between 1 and %d", ErrInvalidVoucherBatch, maxVoucherBatchSize)
+	}
+	if batch.MaxRedemptions == 0 {
+		return fmt.Errorf("%w: max_redemptions must be at least 1", ErrInvalidVoucherBatch)
+	}
+	if len(prefix) > maxVoucherPrefixLen || strings.Trim(prefix, "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789") != "" {
+		return fmt.Errorf("%w: prefix must be up to %d letters and digits", ErrInvalidVoucherBatch, maxVoucherPrefixLen)
+	}
+	err := pricing.ValidateDiscount(batch.DiscountType, batch.Percent, batch.Discount)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidVoucherBatch, err)
+	}
+	return nil
+}
+
+// generateVoucherCode creates a random code like BREW-K7QM-X3TA, 40 bits of randomness make codes hard to guess
+func generateVoucherCode(prefix string) string {
+	random := make([]byte, 8)
+	_, _ = rand.Read(random)
+	var code strings.Builder
+	if prefix != "" {
+		code.WriteString(prefix)
+		code.WriteByte('-')
+	}
+	for i, b := range random {
+		if i == 4 {
+			code.WriteByte('-')
+		}
+		// the alphabet has 32 characters, so this is unbiased
+		code.WriteByte(voucherAlphabet[b%byte(len(voucherAlphabet))])
+	}
+	return code.String()
+}
+
+// normalizeVoucherCode makes codes case insensitive and ignores surrounding spaces
+func normalizeVoucherCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -1,79 +1,106 @@
 package rest
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"ordersystem/auth"
 	"ordersystem/httptools"
 	"ordersystem/model"
+	"ordersystem/pricing"
+	"ordersystem/problem"
+	"ordersystem/receipt"
 	"ordersystem/repository"
+	"ordersystem/serving"
 	"ordersystem/storage"
-	"strings"
+	"ordersystem/validation"
 
 	"github.com/go-chi/render"
 	"github.com/minio/minio-go/v7"
 	"gorm.io/gorm"
 )
 
-// GetMenu 			godoc
-// @tags 			Menu
-// @Description 	Returns the menu of all drinks
-// @Produce  		json
-// @Success 		200 {array} model.Drink
-// @Failure     	500
-// @Router 			/api/menu [get]
-func GetMenu(db *repository.DatabaseHandler) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		allDrinks, err := db.GetDrinks()
-		if err != nil {
-			slog.Error("Unable to load drinks", slog.String("error", err.Error()))
-			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, "Unable to load drinks")
-			return
-		}
-		render.Status(r, http.StatusOK)
-		render.JSON(w, r, allDrinks)
-	}
-}
-
 // GetOrders		godoc
 // @tags 			Order
-// @Description 	Returns all orders
+// @Description 	Returns one page of orders. The total number of matching orders is returned in the X-Total-Count header,
+// @Description 	the next page is linked in the Link header.
 // @Produce  		json
+// @Param 			status query []string false "Order status" collectionFormat(csv) Enums(placed, preparing, served, paid, cancelled)
+// @Param 			drink_id query int false "Only orders containing this drink"
+// @Param 			from query string false "Created at or after (RFC 3339)"
+// @Param 			to query string false "Created before (RFC 3339)"
+// @Param 			min_amount query int false "Minimum total quantity"
+// @Param 			max_amount query int false "Maximum total quantity"
+// @Param 			sort query string false "Sort field, prefix with - for descending" Enums(id, -id, created_at, -created_at, amount, -amount)
+// @Param 			limit query int false "Page size (default 100, max 1000)"
+// @Param 			cursor query string false "Opaque cursor of the next page, only valid with the sort it was issued for"
 // @Success 		200 {array} model.Order
-// @Failure     	500
+// @Header 			200 {integer} X-Total-Count "Number of matching orders"
+// @Header 			200 {string} Link "Link to the next page"
+// @Failure     	400 {object} problem.Problem
+// @Failure     	401 {object} problem.Problem
+// @Failure     	403 {object} problem.Problem
+// @Failure     	500 {object} problem.Problem
+// @Security 		BearerAuth
+// @Security 		ApiKeyAuth
 // @Router 			/api/order/all [get]
 func GetOrders(db *repository.DatabaseHandler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		allOrders, err := db.GetOrders()
+		query, err := parseOrderQuery(r)
+		if err != nil {
+			renderProblem(w, r, http.StatusBadRequest, problem.CodeInvalidParameter, err.Error())
+			return
+		}
+		orders, total, next, err := db.GetOrders(query)
 		if err != nil {
-			slog.Error("Unable to load orders", slog.String("error", err.Error()))
-			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, "Unable to load order")
+			renderError(w, r, err, "Unable to load order")
 			return
 		}
+		var nextCursor string
+		if next != nil {
+			nextCursor, err = httptools.EncodeCursor(next)
+			if err != nil {
+				renderError(w, r, err, "Unable to load order")
+				return
+			}
+		}
+		httptools.SetPaginationHeaders(w, r, total, nextCursor)
 		render.Status(r, http.StatusOK)
-		render.JSON(w, r, allOrders)
+		render.JSON(w, r, orders)
 	}
 }
 
 // GetOrdersTotal		godoc
 // @tags 				Order
-// @Description 		Gets totalled orders
+// @Description 		Gets quantity, gross revenue and average unit price per drink
 // @Produce  			json
-// @Success 			200
-// @Failure     		500
+// @Param 				from query string false "Created at or after (RFC 3339)"
+// @Param 				to query string false "Created before (RFC 3339)"
+// @Success 			200 {array} model.DrinkOrderTotal
+// @Failure     		400 {object} problem.Problem
+// @Failure     		401 {object} problem.Problem
+// @Failure     		403 {object} problem.Problem
+// @Failure     		500 {object} problem.Problem
+// @Security 			BearerAuth
+// @Security 			ApiKeyAuth
 // @Router 				/api/order/totalled [get]
 func GetOrdersTotal(db *repository.DatabaseHandler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		totalledOrders, err := db.GetTotalledOrders()
+		from, err := httptools.ParseOptionalTimeQueryParam("from", r)
 		if err != nil {
-			slog.Error("Unable to load order totals", slog.String("error", err.Error()))
-			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, "Unable to load order totals")
+			renderProblem(w, r, http.StatusBadRequest, problem.CodeInvalidParameter, err.Error())
+			return
+		}
+		to, err := httptools.ParseOptionalTimeQueryParam("to", r)
+		if err != nil {
+			renderProblem(w, r, http.StatusBadRequest, problem.CodeInvalidParameter, err.Error())
+			return
+		}
+		totalledOrders, err := db.GetTotalledOrders(from, to)
+		if err != nil {
+			renderError(w, r, err, "Unable to load order totals")
 			return
 		}
 		render.Status(r, http.StatusOK)
@@ -83,47 +110,113 @@ func GetOrdersTotal(db *repository.DatabaseHandler) http.HandlerFunc {
 
 // GetReceiptFile		godoc
 // @tags 				Order
-// @Description 		Get receipt for order
+// @Description 		Get receipt for order, answers 202 while the receipt is being written.
+// @Description 		The format is chosen by the Accept header, the format query param takes precedence.
+// @Description 		Depending on delivery the receipt is streamed (proxy), returned as presigned link (url)
+// @Description 		or the client is redirected to the presigned link (redirect). The default is configured by RECEIPT_DELIVERY.
+// @Description 		Customers only get receipts of their own orders.
+// @Description 		Only markdown receipts use the configurable receipt template. PDF receipts contain the rendered markdown as
+// @Description 		plain text without layout, HTML receipts use a fixed built-in layout and JSON receipts are the order itself.
 // @Produce 			text/markdown
-// @Success 			200 {file} markdown file
+// @Produce 			text/html
+// @Produce 			application/json
+// @Produce 			application/pdf
+// @Success 			200 {file} receipt file
+// @Success 			202
+// @Success 			302
 // @Param 				orderId path int true "Order ID"
-// @Failure     		404
-// @Failure     		500
+// @Param 				format query string false "Receipt format" Enums(markdown, html, json, pdf)
+// @Param 				delivery query string false "Receipt delivery" Enums(proxy, url, redirect)
+// @Failure     		400 {object} problem.Problem
+// @Failure     		401 {object} problem.Problem
+// @Failure     		403 {object} problem.Problem
+// @Failure     		404 {object} problem.Problem
+// @Failure     		406 {object} problem.Problem
+// @Failure     		410 {object} problem.Problem
+// @Failure     		500 {object} problem.Problem
+// @Security 			BearerAuth
+// @Security 			ApiKeyAuth
 // @Router 				/api/receipt/{orderId} [get]
-func GetReceiptFile(db *repository.DatabaseHandler, s3 *minio.Client) http.HandlerFunc {
+func GetReceiptFile(db *repository.DatabaseHandler, s3 *minio.Client, renderer *receipt.Renderer, linker *storage.ReceiptLinker) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		uintId, err := httptools.ParseUintUrlParam("orderId", r)
 		if err != nil {
-			slog.Error("No order id set")
-			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, "No order id set")
+			renderProblem(w, r, http.StatusBadRequest, problem.CodeInvalidParameter, "No order id set")
+			return
+		}
+		format, formatProblem := negotiateReceiptFormat(r)
+		if formatProblem != nil {
+			problem.Write(w, r, formatProblem)
 			return
 		}
+		delivery := linker.Delivery()
+		if value := r.URL.Query().Get("delivery"); value != "" {
+			delivery, err = storage.ParseReceiptDelivery(value)
+			if err != nil {
+				renderProblem(w, r, http.StatusBadRequest, problem.CodeInvalidParameter, err.Error())
+				return
+			}
+		}
 		// get order from db
 		order, err := db.GetOrder(uintId)
+		if order != nil && !canAccessOrder(r, order) {
+			// do not reveal orders of other customers
+			err = gorm.ErrRecordNotFound
+		}
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				render.Status(r, http.StatusBadRequest)
-				render.JSON(w, r, "This order does not exist")
+				renderProblem(w, r, http.StatusNotFound, problem.CodeOrderNotFound, "This order does not exist")
+				return
+			}
+			if errors.Is(err, repository.ErrOrderCancelled) {
+				renderProblem(w, r, http.StatusGone, problem.CodeOrderCancelled, fmt.Sprintf("Order %d has been cancelled", uintId))
 				return
 			}
-			slog.Error("Unable to load order", slog.String("error", err.Error()))
-			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, "Unable to load order")
+			renderError(w, r, err, "Unable to load order")
 			return
 		}
+		switch order.ReceiptStatus {
+		case model.ReceiptPending:
+			receiptPending(w, r)
+			return
+		case model.ReceiptFailed:
+			renderProblem(w, r, http.StatusInternalServerError, problem.CodeReceiptFailed, "Unable to write order receipt")
+			return
+		}
+		if delivery != storage.DeliverProxy {
+			link, err := presignReceipt(r, s3, db, renderer, linker, order, format)
+			if errors.Is(err, repository.ErrReceiptBusy) {
+				receiptPending(w, r)
+				return
+			}
+			if err == nil {
+				if delivery == storage.DeliverRedirect {
+					http.Redirect(w, r, link.URL, http.StatusFound)
+					return
+				}
+				render.Status(r, http.StatusOK)
+				render.JSON(w, r, link)
+				return
+			}
+			// fall back to proxying
+			slog.Warn("Unable to presign receipt, proxying instead", slog.String("error", err.Error()))
+		}
 		// read from s3
-		receipt, err := s3.GetObject(r.Context(), storage.OrdersBucket, order.GetFilename(), minio.GetObjectOptions{})
+		receiptFile, err := storage.GetReceipt(r.Context(), s3, db, renderer, order, format)
+		if errors.Is(err, repository.ErrReceiptBusy) {
+			receiptPending(w, r)
+			return
+		}
 		if err != nil {
-			slog.Error("Unable to get order receipt from S3", slog.String("error", err.Error()))
-			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, "Unable to get order receipt from S3")
+			renderError(w, r, err, "Unable to get order receipt from S3")
 			return
 		}
+		defer receiptFile.Close()
 		// serve file
-		w.Header().Set("Content-Type", "text/markdown")
-		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", order.GetFilename()))
-		_, err = io.Copy(w, receipt)
+		w.Header().Set("Content-Type", format.ContentType())
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", format.Filename(order)))
+		w.Header().Set("Vary", "Accept")
+		_, err = io.Copy(w, receiptFile)
 		if err != nil {
 			slog.Error("Error serving receipt", slog.String("error", err.Error()))
 			return
@@ -131,55 +224,251 @@ func GetReceiptFile(db *repository.DatabaseHandler, s3 *minio.Client) http.Handl
 	}
 }
 
+func presignReceipt(r *http.Request, s3 *minio.Client, db *repository.DatabaseHandler, renderer *receipt.Renderer,
+	linker *storage.ReceiptLinker, order *model.Order, format receipt.Format) (*model.ReceiptLink, error) {
+	err := storage.EnsureReceipt(r.Context(), s3, db, renderer, order, format)
+	if err != nil {
+		return nil, err
+	}
+	presigned, expiresAt, err := linker.PresignReceipt(r.Context(), order, format)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ReceiptLink{URL: presigned.String(), ExpiresAt: expiresAt}, nil
+}
+
+// receiptPending asks the client to retry while the receipt is being written
+func receiptPending(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Retry-After", "1")
+	render.Status(r, http.StatusAccepted)
+	render.JSON(w, r, "Receipt is being written, please retry")
+}
+
+// negotiateReceiptFormat picks the receipt format from the format query param or the Accept header
+func negotiateReceiptFormat(r *http.Request) (receipt.Format, *problem.Problem) {
+	if name := r.URL.Query().Get("format"); name != "" {
+		format, err := receipt.ParseFormat(name)
+		if err != nil {
+			return "", problem.New(http.StatusBadRequest, problem.CodeUnknownFormat, err.Error())
+		}
+		return format, nil
+	}
+	format, ok := receipt.Negotiate(r.Header.Get("Accept"))
+	if !ok {
+		return "", problem.New(http.StatusNotAcceptable, problem.CodeNotAcceptable, "no acceptable receipt format, use one of "+
+			"text/markdown, text/html, application/json, application/pdf")
+	}
+	return format, nil
+}
+
 // PostOrder 		godoc
 // @tags 			Order
-// @Description 	Adds an order to the db
+// @Description 	Adds an order with one or more drinks to the db.
+// @Description 	Retries with the same Idempotency-Key return the original response without placing the order again.
+// @Description 	The order is recorded as created by the authenticated principal. With tab_id it is put on that open tab.
+// @Description 	Orders exceeding the stock of a drink are rejected with 409.
+// @Description 	Unit prices are taken from the menu after pricing rules, the applied rule is stored on each item.
+// @Description 	An optional voucher_code is redeemed on one unit of an eligible drink, expired or used up vouchers are rejected with 409.
+// @Description 	Orders of customers exceeding the responsible serving limits are flagged or rejected with 403.
+// @Description 	Invalid fields and unknown drinks are answered with 422 listing every field error.
 // @Accept 			json
-// @Param 			b body model.Order true "Order"
+// @Param 			b body model.NewOrder true "Order"
+// @Param 			Idempotency-Key header string false "Unique key of this order submission"
 // @Produce  		json
-// @Success 		200
-// @Failure     	400
-// @Failure     	500
+// @Success 		200 {object} model.Order
+// @Failure     	400 {object} problem.Problem
+// @Failure     	401 {object} problem.Problem
+// @Failure     	403 {object} problem.Problem
+// @Failure     	409 {object} problem.Problem
+// @Failure     	413 {object} problem.Problem
+// @Failure     	422 {object} problem.Problem
+// @Failure     	500 {object} problem.Problem
+// @Security 		BearerAuth
+// @Security 		ApiKeyAuth
 // @Router 			/api/order [post]
-func PostOrder(db *repository.DatabaseHandler, s3 *minio.Client) http.HandlerFunc {
+func PostOrder(db *repository.DatabaseHandler, engine *pricing.Engine, policy *serving.Policy) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var order model.Order
-		// read body
-		payload, err := io.ReadAll(r.Body)
+		var newOrder model.NewOrder
+		err := httptools.DecodeJSON(w, r, &newOrder, maxBodyBytes)
 		if err != nil {
-			slog.Error("Unable to read body", slog.String("error", err.Error()))
-			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, "Unable to read body")
+			renderError(w, r, err, "Unable to decode body")
 			return
 		}
-		err = json.Unmarshal(payload, &order)
+		fieldErrs := validation.Check(&newOrder)
+		// unknown drinks are reported together with the other field errors
+		drinkIDs := make([]uint, len(newOrder.Items))
+		for i, item := range newOrder.Items {
+			drinkIDs[i] = item.DrinkID
+		}
+		existing, err := db.GetExistingDrinkIDs(drinkIDs)
 		if err != nil {
-			slog.Error("Unable to decode body", slog.String("error", err.Error()))
-			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, "Unable to decode body")
+			renderError(w, r, err, "Unable to load drinks")
 			return
 		}
+		for i, item := range newOrder.Items {
+			if item.DrinkID != 0 && !existing[item.DrinkID] {
+				fieldErrs.Add(fmt.Sprintf("items[%d].drink_id", i), "exists", "is not a drink on the menu")
+			}
+		}
+		if len(fieldErrs) > 0 {
+			renderError(w, r, fieldErrs, "Invalid order")
+			return
+		}
+		order := newOrder.ToOrder()
+		// the order belongs to the caller, never to a client supplied principal
+		principal, _ := auth.PrincipalFromContext(r.Context())
+		order.CreatedBy = principal.Subject
+		if order.TabID != nil {
+			// customers can only order on their own tabs, missing tabs are reported by AddOrder
+			tab, err := db.GetTab(*order.TabID)
+			if err == nil && !canAccessTab(r, tab) {
+				renderError(w, r, repository.ErrUnknownTab, "Unable to add order to db")
+				return
+			}
+		}
+		// staff order for several patrons, the serving limits only apply to customers
+		customerPolicy := policy
+		if principal.Role.Includes(auth.RoleBartender) {
+			customerPolicy = nil
+		}
 		// store to db
-		dbOrder, err := db.AddOrder(&order)
+		dbOrder, err := db.AddOrder(order, engine, customerPolicy)
+		if err != nil {
+			renderError(w, r, err, "Unable to add order to db")
+			return
+		}
+		recordOrder(dbOrder)
+		// receipt is written by the outbox dispatcher
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, dbOrder)
+	}
+}
+
+// PatchOrderStatus		godoc
+// @tags 				Order
+// @Description 		Moves an order to a new status (placed -> preparing -> served -> paid, or cancelled)
+// @Accept 				json
+// @Param 				orderId path int true "Order ID"
+// @Param 				b body model.OrderStatusUpdate true "New status"
+// @Produce  			json
+// @Success 			200 {object} model.Order
+// @Failure     		400 {object} problem.Problem
+// @Failure     		401 {object} problem.Problem
+// @Failure     		403 {object} problem.Problem
+// @Failure     		404 {object} problem.Problem
+// @Failure     		409 {object} problem.Problem
+// @Failure     		413 {object} problem.Problem
+// @Failure     		422 {object} problem.Problem
+// @Failure     		500 {object} problem.Problem
+// @Security 			BearerAuth
+// @Security 			ApiKeyAuth
+// @Router 				/api/order/{orderId}/status [patch]
+func PatchOrderStatus(db *repository.DatabaseHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uintId, err := httptools.ParseUintUrlParam("orderId", r)
 		if err != nil {
-			slog.Error("Unable to add order to db", slog.String("error", err.Error()))
-			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, "Unable to add order to db")
+			renderProblem(w, r, http.StatusBadRequest, problem.CodeInvalidParameter, "No order id set")
 			return
 		}
-		// store to s3
-		mardkown := dbOrder.ToMarkdown()
-		receiptReader := strings.NewReader(mardkown)
-		_, err = s3.PutObject(r.Context(), storage.OrdersBucket, dbOrder.GetFilename(), receiptReader, int64(len(mardkown)),
-			minio.PutObjectOptions{ContentType: "text/markdown"})
+		var update model.OrderStatusUpdate
+		err = decodeRequest(w, r, &update)
 		if err != nil {
-			slog.Error("Unable to create order receipt", slog.String("error", err.Error()))
-			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, "Unable to create order receipt")
+			renderError(w, r, err, "Unable to decode body")
 			return
 		}
+		order, err := db.UpdateOrderStatus(uintId, update.Status)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				renderProblem(w, r, http.StatusNotFound, problem.CodeOrderNotFound, "This order does not exist")
+				return
+			}
+			renderError(w, r, err, "Unable to update order status")
+			return
+		}
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, order)
+	}
+}
 
+// CancelOrder		godoc
+// @tags 			Order
+// @Description 	Cancels an order, the receipt is replaced by a void one in the background
+// @Accept 			json
+// @Param 			orderId path int true "Order ID"
+// @Param 			b body model.OrderCancellation false "Cancellation reason"
+// @Produce  		json
+// @Success 		200 {object} model.Order
+// @Failure     	400 {object} problem.Problem
+// @Failure     	401 {object} problem.Problem
+// @Failure     	403 {object} problem.Problem
+// @Failure     	404 {object} problem.Problem
+// @Failure     	409 {object} problem.Problem
+// @Failure     	413 {object} problem.Problem
+// @Failure     	422 {object} problem.Problem
+// @Failure     	500 {object} problem.Problem
+// @Security 		BearerAuth
+// @Security 		ApiKeyAuth
+// @Router 			/api/order/{orderId} [delete]
+func CancelOrder(db *repository.DatabaseHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uintId, err := httptools.ParseUintUrlParam("orderId", r)
+		if err != nil {
+			renderProblem(w, r, http.StatusBadRequest, problem.CodeInvalidParameter, "No order id set")
+			return
+		}
+		// reason is optional
+		var cancellation model.OrderCancellation
+		err = decodeRequest(w, r, &cancellation)
+		if err != nil && !errors.Is(err, io.EOF) {
+			renderError(w, r, err, "Unable to decode body")
+			return
+		}
+		order, err := db.CancelOrder(uintId, cancellation.Reason)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				renderProblem(w, r, http.StatusNotFound, problem.CodeOrderNotFound, "This order does not exist")
+				return
+			}
+			renderError(w, r, err, "Unable to cancel order")
+			return
+		}
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, order)
+	}
+}
+
+// RestoreOrder		godoc
+// @tags 			Order
+// @Description 	Undoes the cancellation of an order (admin only)
+// @Param 			orderId path int true "Order ID"
+// @Produce  		json
+// @Success 		200 {object} model.Order
+// @Failure     	400 {object} problem.Problem
+// @Failure     	401 {object} problem.Problem
+// @Failure     	403 {object} problem.Problem
+// @Failure     	404 {object} problem.Problem
+// @Failure     	409 {object} problem.Problem
+// @Failure     	500 {object} problem.Problem
+// @Security 		BearerAuth
+// @Security 		ApiKeyAuth
+// @Router 			/api/order/{orderId}/restore [post]
+func RestoreOrder(db *repository.DatabaseHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uintId, err := httptools.ParseUintUrlParam("orderId", r)
+		if err != nil {
+			renderProblem(w, r, http.StatusBadRequest, problem.CodeInvalidParameter, "No order id set")
+			return
+		}
+		order, err := db.RestoreOrder(uintId)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				renderProblem(w, r, http.StatusNotFound, problem.CodeOrderNotFound, "This order does not exist")
+				return
+			}
+			renderError(w, r, err, "Unable to restore order")
+			return
+		}
 		render.Status(r, http.StatusOK)
-		render.JSON(w, r, "ok")
+		render.JSON(w, r, order)
 	}
 }
@@ -0,0 +1,204 @@
+package rest
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"ordersystem/auth"
+	"ordersystem/httptools"
+	"ordersystem/model"
+	"ordersystem/problem"
+	"ordersystem/ratelimit"
+	"ordersystem/repository"
+	"strings"
+
+	"github.com/go-chi/render"
+	"gorm.io/gorm"
+)
+
+const (
+	APIKeyHeader  = "X-API-Key"
+	bearerPrefix  = "Bearer "
+	bearerType    = "Bearer"
+	authChallenge = `Bearer realm="ordersystem"`
+)
+
+// Authenticate resolves the bearer token of the Authorization header or the api key of the X-API-Key header
+// into the principal of the request. Invalid credentials are rejected with 401, requests without credentials
+// pass anonymously and are left to RequireRole.
+// Every rejection takes a token of the failed_auth limit of the client ip. Once it is used up, credentials of
+// the client are not checked anymore until it refills and the requests get a 429, so keys and tokens cannot be
+// guessed at the speed of the server.
+func Authenticate(authenticator *auth.Authenticator, limiter *ratelimit.Limiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			key := r.Header.Get(APIKeyHeader)
+			if header == "" && key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			client := "ip:" + httptools.ClientIP(r, limiter.TrustedProxies())
+			result, err := limiter.Peek(r.Context(), ratelimit.FailedAuthRoute, client)
+			if err != nil {
+				slog.Error("Unable to check failed authentication limit", slog.String("error", err.Error()))
+			} else if !result.Allowed {
+				tooManyRequests(w, r, result, "Too many failed authentication attempts, retry later")
+				return
+			}
+			rejected := func(message string) {
+				_, err := limiter.Allow(r.Context(), ratelimit.FailedAuthRoute, client)
+				if err != nil {
+					slog.Error("Unable to count failed authentication", slog.String("error", err.Error()))
+				}
+				unauthorized(w, r, message)
+			}
+			var principal auth.Principal
+			if header != "" {
+				token, ok := strings.CutPrefix(header, bearerPrefix)
+				if !ok {
+					rejected("Authorization header must be a bearer token")
+					return
+				}
+				principal, err = authenticator.VerifyToken(strings.TrimSpace(token))
+				if err != nil {
+					rejected(err.Error())
+					return
+				}
+			} else {
+				var ok bool
+				principal, ok = authenticator.LookupAPIKey(key)
+				if !ok {
+					rejected("Invalid api key")
+					return
+				}
+			}
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// RequireRole only lets authenticated requests pass whose role includes the required role.
+// Anonymous requests get a 401, requests with a lower role a 403.
+func RequireRole(role auth.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := auth.PrincipalFromContext(r.Context())
+			if !ok {
+				unauthorized(w, r, "Authentication required")
+				return
+			}
+			if !principal.Role.Includes(role) {
+				renderProblem(w, r, http.StatusForbidden, problem.CodeForbidden, "Role "+string(role)+" required")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request, message string) {
+	w.Header().Set("WWW-Authenticate", authChallenge)
+	renderProblem(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, message)
+}
+
+// canAccessOrder reports whether the principal of the request may see the order.
+// Customers only see their own orders, bartenders and admins see all.
+func canAccessOrder(r *http.Request, order *model.Order) bool {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		return false
+	}
+	return principal.Role.Includes(auth.RoleBartender) || order.CreatedBy == principal.Subject
+}
+
+// canAccessTab reports whether the principal of the request may see the tab.
+// Customers only see the tabs they opened, bartenders and admins see all.
+func canAccessTab(r *http.Request, tab *model.Tab) bool {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		return false
+	}
+	return principal.Role.Includes(auth.RoleBartender) || tab.OpenedBy == principal.Subject
+}
+
+// PostToken		godoc
+// @tags 			Auth
+// @Description 	Exchanges username and password for a bearer token
+// @Accept 			json
+// @Param 			b body model.Credentials true "Credentials"
+// @Produce  		json
+// @Success 		200 {object} model.AccessToken
+// @Failure     	400 {object} problem.Problem
+// @Failure     	401 {object} problem.Problem
+// @Failure     	413 {object} problem.Problem
+// @Failure     	422 {object} problem.Problem
+// @Failure     	500 {object} problem.Problem
+// @Router 			/api/auth/token [post]
+func PostToken(db *repository.DatabaseHandler, authenticator *auth.Authenticator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var credentials model.Credentials
+		err := decodeRequest(w, r, &credentials)
+		if err != nil {
+			renderError(w, r, err, "Unable to decode body")
+			return
+		}
+		var passwordHash string
+		user, err := db.GetUserByName(credentials.Username)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			renderError(w, r, err, "Unable to log in")
+			return
+		}
+		if user != nil {
+			passwordHash = user.PasswordHash
+		}
+		// unknown users are checked against a dummy hash, so they cannot be told apart by timing
+		if !authenticator.CheckPassword(passwordHash, credentials.Password) {
+			unauthorized(w, r, "Invalid username or password")
+			return
+		}
+		principal := auth.Principal{Subject: user.Username, Role: user.Role}
+		token, expiresAt, err := authenticator.IssueToken(principal)
+		if err != nil {
+			renderError(w, r, err, "Unable to log in")
+			return
+		}
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, model.AccessToken{AccessToken: token, TokenType: bearerType, ExpiresAt: expiresAt, Role: user.Role})
+	}
+}
+
+// PostUser			godoc
+// @tags 			Auth
+// @Description 	Creates a user that can log in at /api/auth/token
+// @Accept 			json
+// @Param 			b body model.NewUser true "User"
+// @Produce  		json
+// @Success 		201 {object} model.User
+// @Failure     	400 {object} problem.Problem
+// @Failure     	401 {object} problem.Problem
+// @Failure     	403 {object} problem.Problem
+// @Failure     	409 {object} problem.Problem
+// @Failure     	413 {object} problem.Problem
+// @Failure     	422 {object} problem.Problem
+// @Failure     	500 {object} problem.Problem
+// @Security 		BearerAuth
+// @Security 		ApiKeyAuth
+// @Router 			/api/auth/users [post]
+func PostUser(db *repository.DatabaseHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var newUser model.NewUser
+		err := decodeRequest(w, r, &newUser)
+		if err != nil {
+			renderError(w, r, err, "Unable to decode body")
+			return
+		}
+		user, err := db.AddUser(&newUser)
+		if err != nil {
+			renderError(w, r, err, "Unable to add user")
+			return
+		}
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, user)
+	}
+}
@@ -0,0 +1,66 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"ordersystem/auth"
+	"ordersystem/ratelimit"
+	"testing"
+	"time"
+)
+
+func TestAuthenticateLimitsFailedAttempts(t *testing.T) {
+	const adminKey = "admin-key-for-tests"
+	authenticator, err := auth.CreateAuthenticator(auth.Config{
+		JWTSecret:   "a-secret-that-is-long-enough-for-tests",
+		JWTTTL:      time.Hour,
+		AdminAPIKey: adminKey,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(),
+		map[string]ratelimit.Limit{ratelimit.FailedAuthRoute: {Requests: 3, Period: time.Minute}}, nil)
+	handler := Authenticate(authenticator, limiter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	// the requests run in order against the same limiter
+	tests := []struct {
+		name       string
+		remoteAddr string
+		apiKey     string
+		bearer     string
+		wantStatus int
+	}{
+		{"valid key does not count", "10.0.0.1:1234", adminKey, "", http.StatusNoContent},
+		{"first bad key", "10.0.0.1:1234", "guess-1", "", http.StatusUnauthorized},
+		{"second bad key", "10.0.0.1:1234", "guess-2", "", http.StatusUnauthorized},
+		{"bad token counts as well", "10.0.0.1:1234", "", "not-a-token", http.StatusUnauthorized},
+		{"bad key after the limit", "10.0.0.1:1234", "guess-3", "", http.StatusTooManyRequests},
+		{"valid key is not checked after the limit", "10.0.0.1:1234", adminKey, "", http.StatusTooManyRequests},
+		{"anonymous requests are not limited", "10.0.0.1:1234", "", "", http.StatusNoContent},
+		{"other clients keep their attempts", "10.0.0.2:1234", "guess-4", "", http.StatusUnauthorized},
+		{"other clients can still authenticate", "10.0.0.2:1234", adminKey, "", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/order/all", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.apiKey != "" {
+				r.Header.Set(APIKeyHeader, tt.apiKey)
+			}
+			if tt.bearer != "" {
+				r.Header.Set("Authorization", bearerPrefix+tt.bearer)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusTooManyRequests && w.Header().Get(RetryAfterHeader) == "" {
+				t.Errorf("%s header missing", RetryAfterHeader)
+			}
+		})
+	}
+}
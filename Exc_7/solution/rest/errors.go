@@ -0,0 +1,126 @@
+package rest
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"ordersystem/auth"
+	"ordersystem/httptools"
+	"ordersystem/money"
+	"ordersystem/pricing"
+	"ordersystem/problem"
+	"ordersystem/receipt"
+	"ordersystem/repository"
+	"ordersystem/validation"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/minio/minio-go/v7"
+	"gorm.io/gorm"
+)
+
+// knownErrors maps errors of the repository and other packages to their status and code, the first match wins
+var knownErrors = []struct {
+	err    error
+	status int
+	code   problem.Code
+}{
+	{repository.ErrEmptyOrder, http.StatusBadRequest, problem.CodeEmptyOrder},
+	{repository.ErrUnknownDrink, http.StatusBadRequest, problem.CodeUnknownDrink},
+	{repository.ErrMixedCurrencies, http.StatusBadRequest, problem.CodeMixedCurrencies},
+	{money.ErrOverflow, http.StatusBadRequest, problem.CodeAmountOverflow},
+	{repository.ErrIllegalTransition, http.StatusConflict, problem.CodeIllegalTransition},
+	{repository.ErrOrderCancelled, http.StatusConflict, problem.CodeOrderCancelled},
+	{repository.ErrOrderNotCancelled, http.StatusConflict, problem.CodeOrderNotCancelled},
+	{repository.ErrUnknownSort, http.StatusBadRequest, problem.CodeInvalidParameter},
+	{repository.ErrInvalidDrink, http.StatusBadRequest, problem.CodeInvalidDrink},
+	{repository.ErrDrinkNameTaken, http.StatusConflict, problem.CodeDrinkNameTaken},
+	{repository.ErrInsufficientStock, http.StatusConflict, problem.CodeInsufficientStock},
+	{repository.ErrInvalidRestock, http.StatusBadRequest, problem.CodeInvalidRestock},
+	{repository.ErrInvalidTab, http.StatusBadRequest, problem.CodeInvalidTab},
+	{repository.ErrUnknownTab, http.StatusBadRequest, problem.CodeUnknownTab},
+	{repository.ErrTabClosed, http.StatusConflict, problem.CodeTabClosed},
+	{repository.ErrTabUnsettled, http.StatusConflict, problem.CodeTabUnsettled},
+	{repository.ErrOrderOnOtherTab, http.StatusConflict, problem.CodeOrderOnOtherTab},
+	{repository.ErrInvalidSplit, http.StatusBadRequest, problem.CodeInvalidSplit},
+	{repository.ErrInvalidUser, http.StatusBadRequest, problem.CodeInvalidUser},
+	{repository.ErrUsernameTaken, http.StatusConflict, problem.CodeUsernameTaken},
+	{auth.ErrPasswordTooShort, http.StatusBadRequest, problem.CodePasswordTooShort},
+	{pricing.ErrInvalidRule, http.StatusBadRequest, problem.CodeInvalidPricingRule},
+	{repository.ErrInvalidVoucherBatch, http.StatusBadRequest, problem.CodeInvalidBatch},
+	{repository.ErrUnknownVoucher, http.StatusBadRequest, problem.CodeUnknownVoucher},
+	{repository.ErrVoucherExpired, http.StatusConflict, problem.CodeVoucherExpired},
+	{repository.ErrVoucherUsedUp, http.StatusConflict, problem.CodeVoucherUsedUp},
+	{repository.ErrVoucherNotApplicable, http.StatusBadRequest, problem.CodeVoucherNotApplies},
+	{repository.ErrServingLimitExceeded, http.StatusForbidden, problem.CodeServingLimit},
+	{receipt.ErrUnknownFormat, http.StatusBadRequest, problem.CodeUnknownFormat},
+	{httptools.BadBodyError, http.StatusBadRequest, problem.CodeInvalidBody},
+	{httptools.BadQueryParamError, http.StatusBadRequest, problem.CodeInvalidParameter},
+	{httptools.BadUrlParamError, http.StatusBadRequest, problem.CodeInvalidParameter},
+	{gorm.ErrRecordNotFound, http.StatusNotFound, problem.CodeNotFound},
+	{gorm.ErrDuplicatedKey, http.StatusConflict, problem.CodeConflict},
+}
+
+// NotFound answers requests of unknown routes
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	renderProblem(w, r, http.StatusNotFound, problem.CodeNotFound, "No route for "+r.URL.Path)
+}
+
+// MethodNotAllowed answers requests of known routes with a method they do not support
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	renderProblem(w, r, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, "Method "+r.Method+" is not allowed")
+}
+
+// renderProblem answers with a problem of the given status and code
+func renderProblem(w http.ResponseWriter, r *http.Request, status int, code problem.Code, detail string) {
+	problem.Write(w, r, problem.New(status, code, detail))
+}
+
+// renderError answers with the problem of a known error, message is the detail of all other errors.
+// Invalid fields are answered with 422 listing all of them, errors of the database and S3 are answered with 503 or 502, everything else with 500. Server errors are logged.
+func renderError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	p := toProblem(err, message)
+	if p.Status >= http.StatusInternalServerError {
+		slog.Error(message, slog.String("error", err.Error()),
+			slog.String("request_id", middleware.GetReqID(r.Context())))
+	}
+	problem.Write(w, r, p)
+}
+
+func toProblem(err error, message string) *problem.Problem {
+	var p *problem.Problem
+	if errors.As(err, &p) {
+		return p
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return problem.New(http.StatusRequestEntityTooLarge, problem.CodeBodyTooLarge,
+			fmt.Sprintf("body must not be larger than %d bytes", tooLarge.Limit))
+	}
+	var fieldErrs validation.Errors
+	if errors.As(err, &fieldErrs) {
+		p = problem.New(http.StatusUnprocessableEntity, problem.CodeValidationFailed, err.Error())
+		p.Errors = fieldErrs
+		return p
+	}
+	for _, known := range knownErrors {
+		if errors.Is(err, known.err) {
+			return problem.New(known.status, known.code, err.Error())
+		}
+	}
+	var s3Err minio.ErrorResponse
+	if errors.As(err, &s3Err) {
+		switch s3Err.Code {
+		case "NoSuchKey", "NoSuchBucket":
+			return problem.New(http.StatusNotFound, problem.CodeNotFound, message)
+		}
+		return problem.New(http.StatusBadGateway, problem.CodeStorageUnavailable, message)
+	}
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.As(err, &netErr) {
+		return problem.New(http.StatusServiceUnavailable, problem.CodeUnavailable, message)
+	}
+	return problem.New(http.StatusInternalServerError, problem.CodeInternal, message)
+}
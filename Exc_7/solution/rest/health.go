@@ -0,0 +1,90 @@
+package rest
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"ordersystem/model"
+	"ordersystem/repository"
+	"ordersystem/storage"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/minio/minio-go/v7"
+)
+
+const (
+	// readinessTimeout bounds all checks together, so probes are answered before they time out themselves
+	readinessTimeout = 2 * time.Second
+	healthOK         = "ok"
+	healthDown       = "unavailable"
+	healthDraining   = "draining"
+)
+
+// GetHealth 		godoc
+// @tags 			Health
+// @Description 	Liveness probe, answers as long as the process serves requests
+// @Produce  		json
+// @Success 		200 {object} model.Health
+// @Router 			/healthz [get]
+func GetHealth() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, model.Health{Status: healthOK})
+	}
+}
+
+// GetReadiness 	godoc
+// @tags 			Health
+// @Description 	Readiness probe, checks that postgres and S3 with the orders bucket are reachable.
+// @Description 	Answers 503 if a check fails or the server is shutting down. The reasons of failed checks are only logged.
+// @Produce  		json
+// @Success 		200 {object} model.Health
+// @Failure 		503 {object} model.Health
+// @Router 			/readyz [get]
+func GetReadiness(db *repository.DatabaseHandler, s3 *minio.Client, draining *atomic.Bool) http.HandlerFunc {
+	checks := map[string]func(ctx context.Context) error{
+		"postgres": db.Ping,
+		"s3": func(ctx context.Context) error {
+			return storage.CheckS3(ctx, s3)
+		},
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if draining.Load() {
+			render.Status(r, http.StatusServiceUnavailable)
+			render.JSON(w, r, model.Health{Status: healthDraining})
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		defer cancel()
+		health := model.Health{Status: healthOK, Checks: map[string]string{}}
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for name, check := range checks {
+			wg.Go(func() {
+				result := healthOK
+				err := check(ctx)
+				if err != nil {
+					// errors can contain hosts and credentials, the unauthenticated probe only tells that it failed
+					slog.Warn("Readiness check failed", slog.String("check", name), slog.String("error", err.Error()))
+					result = healthDown
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				health.Checks[name] = result
+				if result != healthOK {
+					health.Status = healthDown
+				}
+			})
+		}
+		wg.Wait()
+		status := http.StatusOK
+		if health.Status != healthOK {
+			status = http.StatusServiceUnavailable
+		}
+		render.Status(r, status)
+		render.JSON(w, r, health)
+	}
+}
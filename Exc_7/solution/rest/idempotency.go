@@ -0,0 +1,155 @@
+package rest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"ordersystem/auth"
+	"ordersystem/httptools"
+	"ordersystem/model"
+	"ordersystem/problem"
+	"ordersystem/repository"
+	"time"
+)
+
+const (
+	IdempotencyKeyHeader        = "Idempotency-Key"
+	IdempotentReplayedHeader    = "Idempotent-Replayed"
+	maxIdempotencyKeyLength     = 255
+	idempotencyPollInterval     = 100 * time.Millisecond
+	idempotencyMaxWait          = 5 * time.Second
+	DefaultIdempotencyRetention = 24 * time.Hour
+	// idempotencyLease is how long a request holds its key, afterwards a retry may take over
+	idempotencyLease = 1 * time.Minute
+)
+
+// Idempotency makes requests with an Idempotency-Key header safe to retry. The response of the first request
+// is stored for the retention period and replayed for every retry without calling the next handler again.
+// Concurrent retries wait for the first request to finish and get a 409 if it takes too long. If the first request
+// fails with a server error or panics the key is released, if its replica dies a retry takes over after the lease.
+// Requests without the header are passed through.
+func Idempotency(db *repository.DatabaseHandler, retention time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				renderProblem(w, r, http.StatusBadRequest, problem.CodeIdempotencyKeyInvalid, "Idempotency-Key is too long")
+				return
+			}
+			// read body to detect reuse of the key for another request
+			payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+			if err != nil {
+				renderError(w, r, fmt.Errorf("%w: %w", httptools.BadBodyError, err), "Unable to read body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(payload))
+			requestHash := hashRequest(r, payload)
+
+			principal, _ := auth.PrincipalFromContext(r.Context())
+			record, claimed, err := db.ClaimIdempotencyKey(principal.Subject, key, requestHash, retention, idempotencyLease)
+			if err != nil {
+				renderError(w, r, err, "Unable to claim idempotency key")
+				return
+			}
+			if !claimed {
+				replayIdempotentResponse(w, r, db, record, requestHash)
+				return
+			}
+
+			completed := false
+			// server errors and panics are not stored, so the client can retry
+			defer func() {
+				if completed {
+					return
+				}
+				err := db.ReleaseIdempotencyKey(record)
+				if err != nil {
+					slog.Error("Unable to release idempotency key", slog.String("key", key), slog.String("error", err.Error()))
+				}
+			}()
+			recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+			if recorder.status >= http.StatusInternalServerError {
+				return
+			}
+			// the request has been processed, if storing its response fails retries take over after the lease
+			completed = true
+			err = db.CompleteIdempotencyKey(record, recorder.status, recorder.Header().Get("Content-Type"), recorder.body.Bytes())
+			if err != nil {
+				slog.Error("Unable to store idempotent response", slog.String("key", key), slog.String("error", err.Error()))
+			}
+		})
+	}
+}
+
+// replayIdempotentResponse answers a retry with the stored response, waiting for the first request if needed
+func replayIdempotentResponse(w http.ResponseWriter, r *http.Request, db *repository.DatabaseHandler, record *model.IdempotencyKey, requestHash string) {
+	if record.RequestHash != requestHash {
+		renderProblem(w, r, http.StatusUnprocessableEntity, problem.CodeIdempotencyKeyReused,
+			"Idempotency-Key has already been used for a different request")
+		return
+	}
+	deadline := time.Now().Add(idempotencyMaxWait)
+	for record.Status != model.IdempotencyCompleted {
+		if time.Now().After(deadline) {
+			renderProblem(w, r, http.StatusConflict, problem.CodeIdempotencyInProgress, "A request with this Idempotency-Key is still in progress")
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(idempotencyPollInterval):
+		}
+		var err error
+		record, err = db.GetIdempotencyKey(record.Principal, record.Key)
+		if err != nil {
+			// first request failed and released the key
+			renderProblem(w, r, http.StatusConflict, problem.CodeIdempotencyInProgress, "The request with this Idempotency-Key failed, please retry")
+			return
+		}
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	if record.ResponseContentType != "" {
+		w.Header().Set("Content-Type", record.ResponseContentType)
+	}
+	w.WriteHeader(record.ResponseStatus)
+	_, err := w.Write(record.ResponseBody)
+	if err != nil {
+		slog.Error("Error replaying response", slog.String("error", err.Error()))
+	}
+}
+
+func hashRequest(r *http.Request, payload []byte) string {
+	hash := sha256.New()
+	// keys of other principals never match, so their responses are not replayed
+	principal, _ := auth.PrincipalFromContext(r.Context())
+	hash.Write([]byte(principal.Subject + "\n"))
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(payload)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder passes the response through while keeping a copy of status and body
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
@@ -0,0 +1,196 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"ordersystem/httptools"
+	"ordersystem/model"
+	"ordersystem/pricing"
+	"ordersystem/problem"
+	"ordersystem/repository"
+
+	"github.com/go-chi/render"
+	"gorm.io/gorm"
+)
+
+// GetMenu 			godoc
+// @tags 			Menu
+// @Description 	Returns the menu of all drinks, drinks running out of stock are flagged with low_stock.
+// @Description 	effective_price is the current price after pricing rules, price the list price.
+// @Produce  		json
+// @Success 		200 {array} model.Drink
+// @Failure     	500 {object} problem.Problem
+// @Router 			/api/menu [get]
+func GetMenu(db *repository.DatabaseHandler, engine *pricing.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		allDrinks, err := db.GetMenu(engine)
+		if err != nil {
+			renderError(w, r, err, "Unable to load drinks")
+			return
+		}
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, allDrinks)
+	}
+}
+
+// PostDrink 		godoc
+// @tags 			Menu
+// @Description 	Adds a drink to the menu
+// @Accept 			json
+// @Param 			b body model.DrinkRequest true "Drink"
+// @Produce  		json
+// @Success 		201 {object} model.Drink
+// @Failure     	400 {object} problem.Problem
+// @Failure     	401 {object} problem.Problem
+// @Failure     	403 {object} problem.Problem
+// @Failure     	409 {object} problem.Problem
+// @Failure     	413 {object} problem.Problem
+// @Failure     	422 {object} problem.Problem
+// @Failure     	500 {object} problem.Problem
+// @Security 		BearerAuth
+// @Security 		ApiKeyAuth
+// @Router 			/api/menu [post]
+func PostDrink(db *repository.DatabaseHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var drink model.DrinkRequest
+		err := decodeRequest(w, r, &drink)
+		if err != nil {
+			renderError(w, r, err, "Unable to decode body")
+			return
+		}
+		dbDrink, err := db.AddDrink(drink.ToDrink())
+		if err != nil {
+			renderDrinkError(w, r, err, "Unable to add drink")
+			return
+		}
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, dbDrink)
+	}
+}
+
+// PutDrink 		godoc
+// @tags 			Menu
+// @Description 	Updates name, price, description, category, standard drinks and low stock threshold of a drink, the stock is changed by restocking
+// @Accept 			json
+// @Param 			drinkId path int true "Drink ID"
+// @Param 			b body model.DrinkRequest true "Drink"
+// @Produce  		json
+// @Success 		200 {object} model.Drink
+// @Failure     	400 {object} problem.Problem
+// @Failure     	401 {object} problem.Problem
+// @Failure     	403 {object} problem.Problem
+// @Failure     	404 {object} problem.Problem
+// @Failure     	409 {object} problem.Problem
+// @Failure     	413 {object} problem.Problem
+// @Failure     	422 {object} problem.Problem
+// @Failure     	500 {object} problem.Problem
+// @Security 		BearerAuth
+// @Security 		ApiKeyAuth
+// @Router 			/api/menu/{drinkId} [put]
+func PutDrink(db *repository.DatabaseHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uintId, err := httptools.ParseUintUrlParam("drinkId", r)
+		if err != nil {
+			renderProblem(w, r, http.StatusBadRequest, problem.CodeInvalidParameter, "No drink id set")
+			return
+		}
+		var drink model.DrinkRequest
+		err = decodeRequest(w, r, &drink)
+		if err != nil {
+			renderError(w, r, err, "Unable to decode body")
+			return
+		}
+		dbDrink, err := db.UpdateDrink(uintId, drink.ToDrink())
+		if err != nil {
+			renderDrinkError(w, r, err, "Unable to update drink")
+			return
+		}
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, dbDrink)
+	}
+}
+
+// DeleteDrink 		godoc
+// @tags 			Menu
+// @Description 	Removes a drink from the menu, drinks that have been ordered before are only retired
+// @Param 			drinkId path int true "Drink ID"
+// @Produce  		json
+// @Success 		200
+// @Failure     	400 {object} problem.Problem
+// @Failure     	401 {object} problem.Problem
+// @Failure     	403 {object} problem.Problem
+// @Failure     	404 {object} problem.Problem
+// @Failure     	500 {object} problem.Problem
+// @Security 		BearerAuth
+// @Security 		ApiKeyAuth
+// @Router 			/api/menu/{drinkId} [delete]
+func DeleteDrink(db *repository.DatabaseHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uintId, err := httptools.ParseUintUrlParam("drinkId", r)
+		if err != nil {
+			renderProblem(w, r, http.StatusBadRequest, problem.CodeInvalidParameter, "No drink id set")
+			return
+		}
+		retired, err := db.RetireDrink(uintId)
+		if err != nil {
+			renderDrinkError(w, r, err, "Unable to remove drink")
+			return
+		}
+		render.Status(r, http.StatusOK)
+		if retired {
+			render.JSON(w, r, "Drink retired")
+			return
+		}
+		render.JSON(w, r, "Drink deleted")
+	}
+}
+
+// RestockDrink 	godoc
+// @tags 			Menu
+// @Description 	Adds servings to the stock of a drink, untracked drinks start tracking their stock
+// @Accept 			json
+// @Param 			drinkId path int true "Drink ID"
+// @Param 			b body model.Restock true "Restock"
+// @Produce  		json
+// @Success 		200 {object} model.Drink
+// @Failure     	400 {object} problem.Problem
+// @Failure     	401 {object} problem.Problem
+// @Failure     	403 {object} problem.Problem
+// @Failure     	404 {object} problem.Problem
+// @Failure     	413 {object} problem.Problem
+// @Failure     	422 {object} problem.Problem
+// @Failure     	500 {object} problem.Problem
+// @Security 		BearerAuth
+// @Security 		ApiKeyAuth
+// @Router 			/api/menu/{drinkId}/restock [post]
+func RestockDrink(db *repository.DatabaseHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uintId, err := httptools.ParseUintUrlParam("drinkId", r)
+		if err != nil {
+			renderProblem(w, r, http.StatusBadRequest, problem.CodeInvalidParameter, "No drink id set")
+			return
+		}
+		var restock model.Restock
+		err = decodeRequest(w, r, &restock)
+		if err != nil {
+			renderError(w, r, err, "Unable to decode body")
+			return
+		}
+		dbDrink, err := db.RestockDrink(uintId, restock.Quantity)
+		if err != nil {
+			renderDrinkError(w, r, err, "Unable to restock drink")
+			return
+		}
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, dbDrink)
+	}
+}
+
+// renderDrinkError maps errors of the drink repository functions to problems
+func renderDrinkError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		renderProblem(w, r, http.StatusNotFound, problem.CodeDrinkNotFound, "This drink does not exist")
+		return
+	}
+	renderError(w, r, err, msg)
+}
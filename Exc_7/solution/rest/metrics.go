@@ -0,0 +1,80 @@
+package rest
+
+import (
+	"net/http"
+	"ordersystem/httptools"
+	"ordersystem/model"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// unmatchedRoute labels requests without route, so unknown paths do not create a series each
+const unmatchedRoute = "unmatched"
+
+var (
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "ordersystem_http_request_duration_seconds",
+		Help: "Duration of http requests by chi route pattern and status",
+	}, []string{"method", "route", "status"})
+	ordersPlaced = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ordersystem_orders_placed_total",
+		Help: "Orders placed by drink, an order with several drinks counts for each of them",
+	}, []string{"drink_id"})
+	drinksOrdered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ordersystem_drinks_ordered_total",
+		Help: "Quantity of drinks ordered",
+	}, []string{"drink_id"})
+	amountOrdered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ordersystem_order_amount_total",
+		Help: "Amount of all orders placed after pricing rules and vouchers",
+	}, []string{"currency"})
+)
+
+// InstrumentRequests observes the duration of every request by its route pattern, i.e. /api/order/{orderId}
+func InstrumentRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		// the pattern is known once the request has been routed
+		route := unmatchedRoute
+		if _, pattern, ok := strings.Cut(httptools.RoutePattern(r), " "); ok {
+			route = pattern
+		}
+		requestDuration.WithLabelValues(r.Method, route, strconv.Itoa(status)).Observe(time.Since(start).Seconds())
+	})
+}
+
+// recordOrder counts the drinks and the amount of a placed order
+func recordOrder(order *model.Order) {
+	counted := map[uint]bool{}
+	for _, item := range order.Items {
+		drinkID := strconv.FormatUint(uint64(item.DrinkID), 10)
+		drinksOrdered.WithLabelValues(drinkID).Add(float64(item.Quantity))
+		if !counted[item.DrinkID] {
+			ordersPlaced.WithLabelValues(drinkID).Inc()
+			counted[item.DrinkID] = true
+		}
+	}
+	total, err := order.Total()
+	if err != nil {
+		return
+	}
+	decimal, err := total.Decimal()
+	if err != nil {
+		return
+	}
+	amount, err := strconv.ParseFloat(decimal, 64)
+	if err == nil && amount > 0 {
+		amountOrdered.WithLabelValues(total.Currency).Add(amount)
+	}
+}
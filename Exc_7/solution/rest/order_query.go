@@ -0,0 +1,80 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"ordersystem/httptools"
+	"ordersystem/model"
+	"ordersystem/repository"
+	"strings"
+)
+
+const (
+	defaultOrderPageSize = 100
+	maxOrderPageSize     = 1000
+)
+
+// parseOrderQuery reads filters, sort and pagination of the order listing from the query params
+func parseOrderQuery(r *http.Request) (query repository.OrderQuery, err error) {
+	query.Statuses, err = parseStatusFilter(r)
+	if err != nil {
+		return query, err
+	}
+	query.DrinkID, err = httptools.ParseOptionalUintQueryParam("drink_id", r)
+	if err != nil {
+		return query, err
+	}
+	query.CreatedFrom, err = httptools.ParseOptionalTimeQueryParam("from", r)
+	if err != nil {
+		return query, err
+	}
+	query.CreatedTo, err = httptools.ParseOptionalTimeQueryParam("to", r)
+	if err != nil {
+		return query, err
+	}
+	query.MinAmount, err = httptools.ParseOptionalUintQueryParam("min_amount", r)
+	if err != nil {
+		return query, err
+	}
+	query.MaxAmount, err = httptools.ParseOptionalUintQueryParam("max_amount", r)
+	if err != nil {
+		return query, err
+	}
+	page, err := httptools.ParsePageParams(r, defaultOrderPageSize, maxOrderPageSize)
+	if err != nil {
+		return query, err
+	}
+	query.Limit = page.Limit
+	query.Sort, err = repository.ParseOrderSort(page.Sort)
+	if err != nil {
+		return query, err
+	}
+	if page.Cursor != "" {
+		query.After = &repository.OrderCursor{}
+		err = httptools.DecodeCursor(page.Cursor, query.After)
+		if err != nil {
+			return query, err
+		}
+		// the position of another sort would skip or repeat orders
+		if query.After.Sort != query.Sort {
+			return query, fmt.Errorf("%w: '%s' was issued for sort '%s', not '%s'",
+				httptools.BadQueryParamError, httptools.CursorParam, query.After.Sort, query.Sort)
+		}
+	}
+	return query, nil
+}
+
+// parseStatusFilter reads the status query param, which can be repeated or comma separated
+func parseStatusFilter(r *http.Request) ([]model.OrderStatus, error) {
+	var statuses []model.OrderStatus
+	for _, param := range r.URL.Query()["status"] {
+		for _, value := range strings.Split(param, ",") {
+			status := model.OrderStatus(strings.TrimSpace(value))
+			if !status.IsValid() {
+				return nil, fmt.Errorf("unknown order status '%s'", value)
+			}
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses, nil
+}
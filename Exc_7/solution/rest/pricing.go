@@ -0,0 +1,106 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"ordersystem/httptools"
+	"ordersystem/model"
+	"ordersystem/problem"
+	"ordersystem/repository"
+
+	"github.com/go-chi/render"
+	"gorm.io/gorm"
+)
+
+// GetPricingRules	godoc
+// @tags 			Pricing
+// @Description 	Returns all pricing rules
+// @Produce  		json
+// @Success 		200 {array} model.PricingRule
+// @Failure     	401 {object} problem.Problem
+// @Failure     	403 {object} problem.Problem
+// @Failure     	500 {object} problem.Problem
+// @Security 		BearerAuth
+// @Security 		ApiKeyAuth
+// @Router 			/api/pricing/rules [get]
+func GetPricingRules(db *repository.DatabaseHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rules, err := db.GetPricingRules()
+		if err != nil {
+			renderError(w, r, err, "Unable to load pricing rules")
+			return
+		}
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, rules)
+	}
+}
+
+// PostPricingRule	godoc
+// @tags 			Pricing
+// @Description 	Adds a pricing rule, i.e. a happy hour. Weekdays and daily window are in the timezone of the venue.
+// @Description 	When several rules match a drink the lowest price wins.
+// @Accept 			json
+// @Param 			b body model.PricingRuleRequest true "Pricing rule"
+// @Produce  		json
+// @Success 		201 {object} model.PricingRule
+// @Failure     	400 {object} problem.Problem
+// @Failure     	401 {object} problem.Problem
+// @Failure     	403 {object} problem.Problem
+// @Failure     	413 {object} problem.Problem
+// @Failure     	422 {object} problem.Problem
+// @Failure     	500 {object} problem.Problem
+// @Security 		BearerAuth
+// @Security 		ApiKeyAuth
+// @Router 			/api/pricing/rules [post]
+func PostPricingRule(db *repository.DatabaseHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var rule model.PricingRuleRequest
+		err := decodeRequest(w, r, &rule)
+		if err != nil {
+			renderError(w, r, err, "Unable to decode body")
+			return
+		}
+		dbRule, err := db.AddPricingRule(rule.ToPricingRule())
+		if err != nil {
+			renderError(w, r, err, "Unable to add pricing rule")
+			return
+		}
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, dbRule)
+	}
+}
+
+// DeletePricingRule	godoc
+// @tags 				Pricing
+// @Description 		Removes a pricing rule, orders placed with it keep their price
+// @Param 				ruleId path int true "Pricing rule ID"
+// @Produce  			json
+// @Success 			200
+// @Failure     		400 {object} problem.Problem
+// @Failure     		401 {object} problem.Problem
+// @Failure     		403 {object} problem.Problem
+// @Failure     		404 {object} problem.Problem
+// @Failure     		500 {object} problem.Problem
+// @Security 			BearerAuth
+// @Security 			ApiKeyAuth
+// @Router 				/api/pricing/rules/{ruleId} [delete]
+func DeletePricingRule(db *repository.DatabaseHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uintId, err := httptools.ParseUintUrlParam("ruleId", r)
+		if err != nil {
+			renderProblem(w, r, http.StatusBadRequest, problem.CodeInvalidParameter, "No pricing rule id set")
+			return
+		}
+		err = db.DeletePricingRule(uintId)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			renderProblem(w, r, http.StatusNotFound, problem.CodeRuleNotFound, "This pricing rule does not exist")
+			return
+		}
+		if err != nil {
+			renderError(w, r, err, "Unable to delete pricing rule")
+			return
+		}
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, "Pricing rule deleted")
+	}
+}
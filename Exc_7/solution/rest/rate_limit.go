@@ -0,0 +1,68 @@
+package rest
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"ordersystem/auth"
+	"ordersystem/httptools"
+	"ordersystem/problem"
+	"ordersystem/ratelimit"
+	"strconv"
+	"time"
+)
+
+const (
+	RetryAfterHeader         = "Retry-After"
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+	RateLimitPolicyHeader    = "RateLimit-Policy"
+)
+
+// RateLimit limits the requests per route of every client with token buckets. Authenticated requests are limited
+// per principal, anonymous ones per client ip. Rejected requests get a 429 with Retry-After, all limited responses
+// carry the RateLimit headers. If the buckets cannot be reached the request is let through.
+func RateLimit(limiter *ratelimit.Limiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client := "ip:" + httptools.ClientIP(r, limiter.TrustedProxies())
+			if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+				client = "principal:" + principal.Subject
+			}
+			result, err := limiter.Allow(r.Context(), httptools.RoutePattern(r), client)
+			if err != nil {
+				slog.Error("Unable to check rate limit", slog.String("error", err.Error()))
+				next.ServeHTTP(w, r)
+				return
+			}
+			setRateLimitHeaders(w, result)
+			if !result.Allowed {
+				tooManyRequests(w, r, result, "Too many requests, retry later")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func setRateLimitHeaders(w http.ResponseWriter, result ratelimit.Result) {
+	if result.Limit.Requests == 0 {
+		return
+	}
+	w.Header().Set(RateLimitLimitHeader, strconv.FormatUint(result.Limit.Requests, 10))
+	w.Header().Set(RateLimitRemainingHeader, strconv.FormatUint(result.Remaining, 10))
+	w.Header().Set(RateLimitResetHeader, seconds(result.Reset))
+	w.Header().Set(RateLimitPolicyHeader, fmt.Sprintf("%d;w=%s", result.Limit.Requests, seconds(result.Limit.Period)))
+}
+
+func tooManyRequests(w http.ResponseWriter, r *http.Request, result ratelimit.Result, message string) {
+	w.Header().Set(RetryAfterHeader, seconds(result.RetryAfter))
+	renderProblem(w, r, http.StatusTooManyRequests, problem.CodeRateLimited, message)
+}
+
+// seconds rounds up to whole seconds, so clients never retry too early
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
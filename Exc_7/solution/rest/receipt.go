@@ -0,0 +1,46 @@
+package rest
+
+import (
+	"log/slog"
+	"net/http"
+	"ordersystem/model"
+	"ordersystem/problem"
+	"ordersystem/receipt"
+)
+
+// PreviewReceipt		godoc
+// @tags 				Order
+// @Description 		Renders a receipt template against a sample order.
+// @Description 		The template only applies to markdown receipts and the PDF receipts made from them, HTML receipts keep their built-in layout.
+// @Accept 				json
+// @Param 				b body model.ReceiptPreview true "Template"
+// @Produce 			text/markdown
+// @Success 			200 {file} markdown file
+// @Failure     		400 {object} problem.Problem
+// @Failure     		401 {object} problem.Problem
+// @Failure     		403 {object} problem.Problem
+// @Failure     		413 {object} problem.Problem
+// @Failure     		422 {object} problem.Problem
+// @Security 			BearerAuth
+// @Security 			ApiKeyAuth
+// @Router 				/api/receipt/preview [post]
+func PreviewReceipt(renderer *receipt.Renderer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var preview model.ReceiptPreview
+		err := decodeRequest(w, r, &preview)
+		if err != nil {
+			renderError(w, r, err, "Unable to decode body")
+			return
+		}
+		rendered, err := renderer.Preview(preview.Template)
+		if err != nil {
+			renderProblem(w, r, http.StatusBadRequest, problem.CodeInvalidTemplate, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", receipt.Markdown.ContentType())
+		_, err = w.Write(rendered)
+		if err != nil {
+			slog.Error("Error serving receipt preview", slog.String("error", err.Error()))
+		}
+	}
+}
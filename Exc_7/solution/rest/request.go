@@ -0,0 +1,23 @@
+package rest
+
+import (
+	"net/http"
+	"ordersystem/httptools"
+	"ordersystem/validation"
+)
+
+// maxBodyBytes limits the size of request bodies, larger ones are answered with 413
+const maxBodyBytes = 1 << 20
+
+// decodeRequest decodes the JSON body into the request webmodel dst and checks the rules of its validate tags
+func decodeRequest(w http.ResponseWriter, r *http.Request, dst any) error {
+	err := httptools.DecodeJSON(w, r, dst, maxBodyBytes)
+	if err != nil {
+		return err
+	}
+	fieldErrs := validation.Check(dst)
+	if len(fieldErrs) > 0 {
+		return fieldErrs
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package rest
+
+import (
+	"net/http"
+	"ordersystem/model"
+	"ordersystem/problem"
+	"ordersystem/repository"
+
+	"github.com/go-chi/render"
+)
+
+// GetServingDecisions	godoc
+// @tags 				Serving
+// @Description 		Returns the latest orders flagged or rejected by the responsible serving policy, newest first
+// @Param 				customer query string false "Only decisions on orders of this principal"
+// @Param 				action query string false "Only flagged or rejected orders" Enums(flag, reject)
+// @Produce  			json
+// @Success 			200 {array} model.ServingDecision
+// @Failure     		400 {object} problem.Problem
+// @Failure     		401 {object} problem.Problem
+// @Failure     		403 {object} problem.Problem
+// @Failure     		500 {object} problem.Problem
+// @Security 			BearerAuth
+// @Security 			ApiKeyAuth
+// @Router 				/api/serving/decisions [get]
+func GetServingDecisions(db *repository.DatabaseHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		action := model.ServingAction(r.URL.Query().Get("action"))
+		if action != "" && !action.IsValid() {
+			renderProblem(w, r, http.StatusBadRequest, problem.CodeInvalidParameter, "Unknown action")
+			return
+		}
+		decisions, err := db.GetServingDecisions(r.URL.Query().Get("customer"), action)
+		if err != nil {
+			renderError(w, r, err, "Unable to load serving decisions")
+			return
+		}
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, decisions)
+	}
+}
@@ -0,0 +1,153 @@
+package rest
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"ordersystem/httptools"
+	"ordersystem/model"
+	"ordersystem/problem"
+	"ordersystem/repository"
+	"strconv"
+	"time"
+)
+
+const (
+	LastEventIDHeader = "Last-Event-ID"
+	// lastEventIDParam allows resuming on the first connect, EventSource only sets the header on reconnects
+	lastEventIDParam = "last_event_id"
+
+	orderStreamPollInterval = time.Second
+	// orderStreamHeartbeat keeps idle connections open behind proxies like traefik
+	orderStreamHeartbeat = 15 * time.Second
+	orderStreamBatchSize = 100
+	orderStreamRetry     = 3 * time.Second
+)
+
+// StreamOrders			godoc
+// @tags 				Order
+// @Description 		Live stream of order events as server-sent events. Each event carries the order after the change.
+// @Description 		Event ids follow the order in which events are committed, reconnecting clients resume after the event
+// @Description 		given in the Last-Event-ID header.
+// @Produce 			text/event-stream
+// @Param 				drink_id query int false "Only events of orders containing this drink"
+// @Param 				last_event_id query int false "Resume after this event id"
+// @Param 				Last-Event-ID header int false "Resume after this event id"
+// @Success 			200 {object} model.Order "Stream of order.created and order.changed events"
+// @Failure     		400 {object} problem.Problem
+// @Failure     		401 {object} problem.Problem
+// @Failure     		403 {object} problem.Problem
+// @Failure     		500 {object} problem.Problem
+// @Security 			BearerAuth
+// @Security 			ApiKeyAuth
+// @Router 				/api/order/stream [get]
+func StreamOrders(db *repository.DatabaseHandler, closing <-chan struct{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			renderProblem(w, r, http.StatusInternalServerError, problem.CodeInternal, "Streaming is not supported")
+			return
+		}
+		drinkID, err := httptools.ParseOptionalUintQueryParam("drink_id", r)
+		if err != nil {
+			renderProblem(w, r, http.StatusBadRequest, problem.CodeInvalidParameter, err.Error())
+			return
+		}
+		lastID, err := parseLastEventID(r)
+		if err != nil {
+			renderProblem(w, r, http.StatusBadRequest, problem.CodeInvalidParameter, err.Error())
+			return
+		}
+		if lastID == nil {
+			// new clients only receive events from now on
+			current, err := db.GetLastOrderEventSequence()
+			if err != nil {
+				renderError(w, r, err, "Unable to stream orders")
+				return
+			}
+			lastID = &current
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		_, err = fmt.Fprintf(w, "retry: %d\n\n", orderStreamRetry.Milliseconds())
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+
+		poll := time.NewTicker(orderStreamPollInterval)
+		defer poll.Stop()
+		heartbeat := time.NewTicker(orderStreamHeartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-closing:
+				// clients reconnect to another replica with their Last-Event-ID
+				return
+			case <-heartbeat.C:
+				_, err = fmt.Fprint(w, ": ping\n\n")
+				if err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-poll.C:
+				events, err := db.GetOrderEvents(*lastID, drinkID, orderStreamBatchSize)
+				if err != nil {
+					// keep the stream open, the database may come back
+					slog.Error("Unable to load order events", slog.String("error", err.Error()))
+					continue
+				}
+				for _, event := range events {
+					err = writeOrderEvent(w, event)
+					if err != nil {
+						return
+					}
+					*lastID = event.Sequence
+				}
+				if len(events) > 0 {
+					flusher.Flush()
+					heartbeat.Reset(orderStreamHeartbeat)
+				}
+			}
+		}
+	}
+}
+
+// parseLastEventID reads the event id to resume after from the header or the query. Returns nil if neither is set.
+func parseLastEventID(r *http.Request) (*uint64, error) {
+	value := r.Header.Get(LastEventIDHeader)
+	if value == "" {
+		value = r.URL.Query().Get(lastEventIDParam)
+	}
+	if value == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: '%s' must be a positive number", httptools.BadQueryParamError, LastEventIDHeader)
+	}
+	return &id, nil
+}
+
+func writeOrderEvent(w http.ResponseWriter, event model.OrderEvent) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\n", event.Sequence, event.Type)
+	if err != nil {
+		return err
+	}
+	// every line of the payload needs its own data field
+	for _, line := range bytes.Split(event.Payload, []byte("\n")) {
+		_, err = fmt.Fprintf(w, "data: %s\n", line)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprint(w, "\n")
+	return err
+}
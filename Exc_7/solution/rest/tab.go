@@ -0,0 +1,255 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"ordersystem/auth"
+	"ordersystem/httptools"
+	"ordersystem/model"
+	"ordersystem/problem"
+	"ordersystem/receipt"
+	"ordersystem/repository"
+	"ordersystem/storage"
+
+	"github.com/go-chi/render"
+	"github.com/minio/minio-go/v7"
+	"gorm.io/gorm"
+)
+
+// OpenTab			godoc
+// @tags 			Tab
+// @Description 	Opens a tab, orders can be put on it until it is closed
+// @Accept 			json
+// @Param 			b body model.TabOpening true "Tab"
+// @Produce  		json
+// @Success 		201 {object} model.Tab
+// @Failure     	400 {object} problem.Problem
+// @Failure     	401 {object} problem.Problem
+// @Failure     	403 {object} problem.Problem
+// @Failure     	413 {object} problem.Problem
+// @Failure     	422 {object} problem.Problem
+// @Failure     	500 {object} problem.Problem
+// @Security 		BearerAuth
+// @Security 		ApiKeyAuth
+// @Router 			/api/tab [post]
+func OpenTab(db *repository.DatabaseHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var opening model.TabOpening
+		err := decodeRequest(w, r, &opening)
+		if err != nil {
+			renderError(w, r, err, "Unable to decode body")
+			return
+		}
+		principal, _ := auth.PrincipalFromContext(r.Context())
+		tab, err := db.OpenTab(opening.Name, principal.Subject)
+		if err != nil {
+			renderError(w, r, err, "Unable to open tab")
+			return
+		}
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, tab)
+	}
+}
+
+// GetTab			godoc
+// @tags 			Tab
+// @Description 	Returns the tab with its orders. The total of open tabs is the running total.
+// @Description 	Customers only see their own tabs.
+// @Param 			tabId path int true "Tab ID"
+// @Produce  		json
+// @Success 		200 {object} model.Tab
+// @Failure     	400 {object} problem.Problem
+// @Failure     	401 {object} problem.Problem
+// @Failure     	403 {object} problem.Problem
+// @Failure     	404 {object} problem.Problem
+// @Failure     	500 {object} problem.Problem
+// @Security 		BearerAuth
+// @Security 		ApiKeyAuth
+// @Router 			/api/tab/{tabId} [get]
+func GetTab(db *repository.DatabaseHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tab, ok := loadTab(w, r, db)
+		if !ok {
+			return
+		}
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, tab)
+	}
+}
+
+// AttachOrder		godoc
+// @tags 			Tab
+// @Description 	Puts an existing order on an open tab. Customers can only put their own orders on their own tabs.
+// @Accept 			json
+// @Param 			tabId path int true "Tab ID"
+// @Param 			b body model.TabAttachment true "Order"
+// @Produce  		json
+// @Success 		200 {object} model.Tab
+// @Failure     	400 {object} problem.Problem
+// @Failure     	401 {object} problem.Problem
+// @Failure     	403 {object} problem.Problem
+// @Failure     	404 {object} problem.Problem
+// @Failure     	409 {object} problem.Problem
+// @Failure     	413 {object} problem.Problem
+// @Failure     	422 {object} problem.Problem
+// @Failure     	500 {object} problem.Problem
+// @Security 		BearerAuth
+// @Security 		ApiKeyAuth
+// @Router 			/api/tab/{tabId}/orders [post]
+func AttachOrder(db *repository.DatabaseHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tab, ok := loadTab(w, r, db)
+		if !ok {
+			return
+		}
+		var attachment model.TabAttachment
+		err := decodeRequest(w, r, &attachment)
+		if err != nil {
+			renderError(w, r, err, "Unable to decode body")
+			return
+		}
+		order, err := db.GetOrder(attachment.OrderID)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (order != nil && !canAccessOrder(r, order)) {
+			renderProblem(w, r, http.StatusNotFound, problem.CodeOrderNotFound, "This order does not exist")
+			return
+		}
+		if err != nil && !errors.Is(err, repository.ErrOrderCancelled) {
+			renderError(w, r, err, "Unable to load order")
+			return
+		}
+		tab, err = db.AttachOrder(tab.ID, attachment.OrderID)
+		if err != nil {
+			renderTabError(w, r, err, "Unable to attach order")
+			return
+		}
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, tab)
+	}
+}
+
+// CloseTab			godoc
+// @tags 			Tab
+// @Description 	Closes the tab: served orders are marked as paid and the bill is split evenly or by line items
+// @Description 	among the payers. The consolidated bill is written to S3 asynchronously.
+// @Accept 			json
+// @Param 			tabId path int true "Tab ID"
+// @Param 			b body model.TabClosing true "Bill split"
+// @Produce  		json
+// @Success 		200 {object} model.Tab
+// @Failure     	400 {object} problem.Problem
+// @Failure     	401 {object} problem.Problem
+// @Failure     	403 {object} problem.Problem
+// @Failure     	404 {object} problem.Problem
+// @Failure     	409 {object} problem.Problem
+// @Failure     	413 {object} problem.Problem
+// @Failure     	422 {object} problem.Problem
+// @Failure     	500 {object} problem.Problem
+// @Security 		BearerAuth
+// @Security 		ApiKeyAuth
+// @Router 			/api/tab/{tabId}/close [post]
+func CloseTab(db *repository.DatabaseHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tab, ok := loadTab(w, r, db)
+		if !ok {
+			return
+		}
+		var closing model.TabClosing
+		err := decodeRequest(w, r, &closing)
+		if err != nil {
+			renderError(w, r, err, "Unable to decode body")
+			return
+		}
+		tab, err = db.CloseTab(tab.ID, &closing)
+		if err != nil {
+			renderTabError(w, r, err, "Unable to close tab")
+			return
+		}
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, tab)
+	}
+}
+
+// GetTabBill		godoc
+// @tags 			Tab
+// @Description 	Get the consolidated bill of a closed tab, answers 202 while the bill is being written
+// @Param 			tabId path int true "Tab ID"
+// @Produce 		text/markdown
+// @Success 		200 {file} markdown file
+// @Success 		202
+// @Failure     	400 {object} problem.Problem
+// @Failure     	401 {object} problem.Problem
+// @Failure     	403 {object} problem.Problem
+// @Failure     	404 {object} problem.Problem
+// @Failure     	409 {object} problem.Problem
+// @Failure     	500 {object} problem.Problem
+// @Security 		BearerAuth
+// @Security 		ApiKeyAuth
+// @Router 			/api/tab/{tabId}/bill [get]
+func GetTabBill(db *repository.DatabaseHandler, s3 *minio.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tab, ok := loadTab(w, r, db)
+		if !ok {
+			return
+		}
+		if tab.Status == model.TabOpen {
+			renderProblem(w, r, http.StatusConflict, problem.CodeTabOpen, "Tab has not been closed yet")
+			return
+		}
+		switch tab.BillStatus {
+		case model.ReceiptPending:
+			w.Header().Set("Retry-After", "1")
+			render.Status(r, http.StatusAccepted)
+			render.JSON(w, r, "Bill is being written, please retry")
+			return
+		case model.ReceiptFailed:
+			renderProblem(w, r, http.StatusInternalServerError, problem.CodeReceiptFailed, "Unable to write tab bill")
+			return
+		}
+		bill, err := storage.GetBill(r.Context(), s3, tab)
+		if err != nil {
+			renderError(w, r, err, "Unable to get tab bill from S3")
+			return
+		}
+		defer bill.Close()
+		w.Header().Set("Content-Type", receipt.Markdown.ContentType())
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", receipt.BillFilename(tab)))
+		_, err = io.Copy(w, bill)
+		if err != nil {
+			slog.Error("Error serving bill", slog.String("error", err.Error()))
+			return
+		}
+	}
+}
+
+// loadTab loads the tab of the tabId url param and writes the error response if it cannot be loaded
+// or belongs to another customer
+func loadTab(w http.ResponseWriter, r *http.Request, db *repository.DatabaseHandler) (*model.Tab, bool) {
+	tabID, err := httptools.ParseUintUrlParam("tabId", r)
+	if err != nil {
+		renderProblem(w, r, http.StatusBadRequest, problem.CodeInvalidParameter, "No tab id set")
+		return nil, false
+	}
+	tab, err := db.GetTab(tabID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (tab != nil && !canAccessTab(r, tab)) {
+		// do not reveal tabs of other customers
+		renderProblem(w, r, http.StatusNotFound, problem.CodeTabNotFound, "This tab does not exist")
+		return nil, false
+	}
+	if err != nil {
+		renderError(w, r, err, "Unable to load tab")
+		return nil, false
+	}
+	return tab, true
+}
+
+// renderTabError maps errors of the tab repository functions to problems, the tab of the url is reported missing
+func renderTabError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	if errors.Is(err, repository.ErrUnknownTab) || errors.Is(err, gorm.ErrRecordNotFound) {
+		renderProblem(w, r, http.StatusNotFound, problem.CodeTabNotFound, "This tab does not exist")
+		return
+	}
+	renderError(w, r, err, message)
+}
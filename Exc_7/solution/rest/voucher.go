@@ -0,0 +1,107 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"ordersystem/httptools"
+	"ordersystem/model"
+	"ordersystem/problem"
+	"ordersystem/repository"
+
+	"github.com/go-chi/render"
+	"gorm.io/gorm"
+)
+
+// PostVoucherBatch	godoc
+// @tags 			Voucher
+// @Description 	Generates a batch of voucher codes. Codes can be single-use (max_redemptions 1) or multi-use,
+// @Description 	expire at expires_at and optionally only apply to one drink. Without discount a voucher is good for a free drink.
+// @Accept 			json
+// @Param 			b body model.NewVoucherBatch true "Voucher batch"
+// @Produce  		json
+// @Success 		201 {object} model.VoucherBatch
+// @Failure     	400 {object} problem.Problem
+// @Failure     	401 {object} problem.Problem
+// @Failure     	403 {object} problem.Problem
+// @Failure     	413 {object} problem.Problem
+// @Failure     	422 {object} problem.Problem
+// @Failure     	500 {object} problem.Problem
+// @Security 		BearerAuth
+// @Security 		ApiKeyAuth
+// @Router 			/api/voucher/batches [post]
+func PostVoucherBatch(db *repository.DatabaseHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var newBatch model.NewVoucherBatch
+		err := decodeRequest(w, r, &newBatch)
+		if err != nil {
+			renderError(w, r, err, "Unable to decode body")
+			return
+		}
+		batch, err := db.AddVoucherBatch(&newBatch)
+		if err != nil {
+			renderError(w, r, err, "Unable to add voucher batch")
+			return
+		}
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, batch)
+	}
+}
+
+// GetVoucherBatch	godoc
+// @tags 			Voucher
+// @Description 	Returns a voucher batch with all its codes and how often they have been redeemed
+// @Param 			batchId path int true "Voucher batch ID"
+// @Produce  		json
+// @Success 		200 {object} model.VoucherBatch
+// @Failure     	400 {object} problem.Problem
+// @Failure     	401 {object} problem.Problem
+// @Failure     	403 {object} problem.Problem
+// @Failure     	404 {object} problem.Problem
+// @Failure     	500 {object} problem.Problem
+// @Security 		BearerAuth
+// @Security 		ApiKeyAuth
+// @Router 			/api/voucher/batches/{batchId} [get]
+func GetVoucherBatch(db *repository.DatabaseHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uintId, err := httptools.ParseUintUrlParam("batchId", r)
+		if err != nil {
+			renderProblem(w, r, http.StatusBadRequest, problem.CodeInvalidParameter, "No voucher batch id set")
+			return
+		}
+		batch, err := db.GetVoucherBatch(uintId)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			renderProblem(w, r, http.StatusNotFound, problem.CodeBatchNotFound, "This voucher batch does not exist")
+			return
+		}
+		if err != nil {
+			renderError(w, r, err, "Unable to load voucher batch")
+			return
+		}
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, batch)
+	}
+}
+
+// GetVoucherUsage	godoc
+// @tags 			Voucher
+// @Description 	Reports per batch how many codes have been redeemed and the discount granted on them.
+// @Description 	Cancelled orders don't count.
+// @Produce  		json
+// @Success 		200 {array} model.VoucherUsage
+// @Failure     	401 {object} problem.Problem
+// @Failure     	403 {object} problem.Problem
+// @Failure     	500 {object} problem.Problem
+// @Security 		BearerAuth
+// @Security 		ApiKeyAuth
+// @Router 			/api/voucher/usage [get]
+func GetVoucherUsage(db *repository.DatabaseHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		usage, err := db.GetVoucherUsage()
+		if err != nil {
+			renderError(w, r, err, "Unable to load voucher usage")
+			return
+		}
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, usage)
+	}
+}
@@ -9,24 +9,37 @@ import (
 const fileSuffix = "_FILE"
 
 func LoadSecretOrEnv(envKey string) (string, error) {
+	secret, ok, err := LookupSecret(envKey)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", errors.New(fmt.Sprintf("environment variable '%s' or '%s_FILE'is not set", envKey, envKey))
+	}
+	return secret, nil
+}
+
+// LookupSecret returns the value of the env variable or the content of the file in envKey_FILE.
+// The bool reports whether either of them is set.
+func LookupSecret(envKey string) (string, bool, error) {
 	envVal, ok := os.LookupEnv(envKey)
 	if ok {
-		return envVal, nil
+		return envVal, true, nil
 	}
 	// lookup file envs
 	envVal, ok = os.LookupEnv(envKey + fileSuffix)
 	if !ok {
-		return "", errors.New(fmt.Sprintf("environment variable '%s' or '%s_FILE'is not set", envKey, envKey))
+		return "", false, nil
 	}
 	// check if file exists
 	_, err := os.Stat(envVal)
 	if err != nil {
-		return "", err
+		return "", true, err
 	}
 	// load secret from file
 	fileContent, err := os.ReadFile(envVal)
 	if err != nil {
-		return "", err
+		return "", true, err
 	}
-	return string(fileContent), nil
+	return string(fileContent), true, nil
 }
@@ -0,0 +1,137 @@
+package serving
+
+import (
+	"errors"
+	"fmt"
+	"ordersystem/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultLimits flag customers after 8 standard drinks within 3 hours and stop serving them after 12,
+// or after 20 within a day
+const defaultLimits = "3h:8:flag,3h:12:reject,24h:20:reject"
+
+var ErrInvalidPolicy = errors.New("invalid serving policy")
+
+// Limit caps the standard drinks a customer can order within a sliding window
+type Limit struct {
+	Window            time.Duration
+	MaxStandardDrinks float64
+	Action            model.ServingAction
+}
+
+// Consumption is an earlier order of the customer
+type Consumption struct {
+	CreatedAt      time.Time
+	StandardDrinks float64
+}
+
+// Policy evaluates the consumption of a customer against the limits before an order is placed
+type Policy struct {
+	limits []Limit
+}
+
+func NewPolicy(limits []Limit) *Policy {
+	return &Policy{limits: limits}
+}
+
+// Config holds the limits as comma separated window:max:action entries, i.e. "3h:8:flag,3h:12:reject".
+// An empty value disables the policy.
+type Config struct {
+	Limits string `yaml:"limits" env:"SERVING_LIMITS" flag:"serving-limits" usage:"comma separated window:max:action limits"`
+}
+
+func DefaultConfig() Config {
+	return Config{Limits: defaultLimits}
+}
+
+// Validate reports all invalid settings
+func (c Config) Validate() error {
+	_, err := parseLimits(c.Limits)
+	if err != nil {
+		return fmt.Errorf("SERVING_LIMITS: %w", err)
+	}
+	return nil
+}
+
+// CreatePolicy creates the policy of the limits in the config
+func CreatePolicy(cfg Config) (*Policy, error) {
+	limits, err := parseLimits(cfg.Limits)
+	if err != nil {
+		return nil, fmt.Errorf("SERVING_LIMITS: %w", err)
+	}
+	return NewPolicy(limits), nil
+}
+
+// parseLimits parses comma separated limits like "3h:8:flag"
+func parseLimits(value string) ([]Limit, error) {
+	var limits []Limit
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("%w: '%s' is not window:max:action", ErrInvalidPolicy, entry)
+		}
+		window, err := time.ParseDuration(parts[0])
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("%w: window '%s' must be a positive duration, i.e. 3h", ErrInvalidPolicy, parts[0])
+		}
+		maxDrinks, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || maxDrinks < 0 {
+			return nil, fmt.Errorf("%w: max '%s' must be a positive number of standard drinks", ErrInvalidPolicy, parts[1])
+		}
+		action := model.ServingAction(parts[2])
+		if action != model.ServingFlag && action != model.ServingReject {
+			return nil, fmt.Errorf("%w: action '%s' must be '%s' or '%s'", ErrInvalidPolicy, parts[2], model.ServingFlag, model.ServingReject)
+		}
+		limits = append(limits, Limit{Window: window, MaxStandardDrinks: maxDrinks, Action: action})
+	}
+	return limits, nil
+}
+
+// Window is the longest window of all limits, older orders don't matter
+func (p *Policy) Window() time.Duration {
+	var window time.Duration
+	for _, limit := range p.limits {
+		window = max(window, limit.Window)
+	}
+	return window
+}
+
+// Evaluate decides on an order with the given standard drinks. Every limit is checked against the orders within its
+// window before now plus the new order, a rejecting limit beats a flagging one. Orders without alcohol are always allowed.
+func (p *Policy) Evaluate(history []Consumption, ordered float64, now time.Time) model.ServingDecision {
+	decision := model.ServingDecision{Action: model.ServingAllow, Ordered: ordered}
+	if ordered <= 0 {
+		return decision
+	}
+	for _, limit := range p.limits {
+		consumed := consumedWithin(history, now.Add(-limit.Window))
+		if consumed+ordered <= limit.MaxStandardDrinks {
+			continue
+		}
+		if decision.Action == model.ServingReject || (decision.Action == model.ServingFlag && limit.Action == model.ServingFlag) {
+			continue
+		}
+		decision.Action = limit.Action
+		decision.Consumed = consumed
+		decision.Limit = limit.MaxStandardDrinks
+		decision.Window = limit.Window.String()
+	}
+	return decision
+}
+
+func consumedWithin(history []Consumption, since time.Time) float64 {
+	var consumed float64
+	for _, c := range history {
+		if c.CreatedAt.After(since) {
+			consumed += c.StandardDrinks
+		}
+	}
+	return consumed
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"ordersystem/model"
+	"ordersystem/receipt"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// PutBill renders the consolidated bill of the closed tab and stores it in the orders bucket
+func PutBill(ctx context.Context, s3 *minio.Client, renderer *receipt.Renderer, tab *model.Tab) error {
+	rendered, err := renderer.Bill(tab)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	_, err = s3.PutObject(ctx, OrdersBucket, receipt.BillFilename(tab), bytes.NewReader(rendered), int64(len(rendered)),
+		minio.PutObjectOptions{ContentType: receipt.Markdown.ContentType()})
+	observeS3("put_object", start, err)
+	return err
+}
+
+// GetBill returns the bill of the tab written by PutBill
+func GetBill(ctx context.Context, s3 *minio.Client, tab *model.Tab) (io.ReadCloser, error) {
+	start := time.Now()
+	object, err := s3.GetObject(ctx, OrdersBucket, receipt.BillFilename(tab), minio.GetObjectOptions{})
+	if err != nil {
+		observeS3("get_object", start, err)
+		return nil, err
+	}
+	// GetObject is lazy, errors show up on first access
+	_, err = object.Stat()
+	observeS3("get_object", start, err)
+	if err != nil {
+		_ = object.Close()
+		return nil, err
+	}
+	return object, nil
+}
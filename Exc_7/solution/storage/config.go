@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultVenueName             = "Order System"
+	defaultReceiptTemplateObject = "receipt.md.tmpl"
+	defaultReceiptURLExpiry      = 5 * time.Minute
+	maxReceiptURLExpiry          = 7 * 24 * time.Hour
+)
+
+// ReceiptConfig holds the settings of rendering and handing out receipts.
+// Without template file or bucket the built-in template is used.
+type ReceiptConfig struct {
+	Venue          string `yaml:"venue" env:"VENUE_NAME" flag:"venue" usage:"venue name printed on receipts"`
+	TemplateFile   string `yaml:"template_file" env:"RECEIPT_TEMPLATE_FILE" flag:"receipt-template-file" usage:"path of a text/template file"`
+	TemplateBucket string `yaml:"template_bucket" env:"RECEIPT_TEMPLATE_BUCKET" flag:"receipt-template-bucket" usage:"bucket to load the template from"`
+	TemplateObject string `yaml:"template_object" env:"RECEIPT_TEMPLATE_OBJECT" flag:"receipt-template-object" usage:"object of the template in the template bucket"`
+	// Delivery is the default delivery, one of proxy, url, redirect
+	Delivery ReceiptDelivery `yaml:"delivery" env:"RECEIPT_DELIVERY" flag:"receipt-delivery" usage:"default receipt delivery, one of proxy, url, redirect"`
+	// URLExpiry is the lifetime of presigned URLs
+	URLExpiry time.Duration `yaml:"url_expiry" env:"RECEIPT_URL_EXPIRY" flag:"receipt-url-expiry" usage:"lifetime of presigned receipt URLs"`
+	// ExternalEndpoint is host:port of S3 as seen by clients, i.e. when S3 is only reachable on the intercom network internally
+	ExternalEndpoint string `yaml:"external_endpoint" env:"S3_EXTERNAL_ENDPOINT" flag:"s3-external-endpoint" usage:"host:port of S3 as seen by clients"`
+	ExternalSecure   bool   `yaml:"external_secure" env:"S3_EXTERNAL_SECURE" flag:"s3-external-secure" usage:"the external S3 endpoint uses https"`
+}
+
+func DefaultReceiptConfig() ReceiptConfig {
+	return ReceiptConfig{
+		Venue:          defaultVenueName,
+		TemplateObject: defaultReceiptTemplateObject,
+		Delivery:       DeliverProxy,
+		URLExpiry:      defaultReceiptURLExpiry,
+	}
+}
+
+// Validate reports all invalid settings
+func (c ReceiptConfig) Validate() error {
+	var errs []error
+	if c.TemplateFile != "" && c.TemplateBucket != "" {
+		errs = append(errs, errors.New("only one of RECEIPT_TEMPLATE_FILE and RECEIPT_TEMPLATE_BUCKET can be set"))
+	}
+	if c.TemplateBucket != "" && c.TemplateObject == "" {
+		errs = append(errs, errors.New("RECEIPT_TEMPLATE_OBJECT must not be empty when RECEIPT_TEMPLATE_BUCKET is set"))
+	}
+	if _, err := ParseReceiptDelivery(string(c.Delivery)); err != nil {
+		errs = append(errs, fmt.Errorf("RECEIPT_DELIVERY: %w", err))
+	}
+	if c.URLExpiry <= 0 || c.URLExpiry > maxReceiptURLExpiry {
+		errs = append(errs, fmt.Errorf("RECEIPT_URL_EXPIRY must be between 1s and %s", maxReceiptURLExpiry))
+	}
+	return errors.Join(errs...)
+}
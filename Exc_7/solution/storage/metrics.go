@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	s3Duration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "ordersystem_s3_operation_duration_seconds",
+		Help: "Duration of S3 operations",
+	}, []string{"operation"})
+	s3Errors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ordersystem_s3_operation_errors_total",
+		Help: "Failed S3 operations by S3 error code, i.e. NoSuchKey",
+	}, []string{"operation", "code"})
+)
+
+// observeS3 records the duration of an S3 operation started at start and its error, if any
+func observeS3(operation string, start time.Time, err error) {
+	s3Duration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err == nil {
+		return
+	}
+	code := minio.ToErrorResponse(err).Code
+	if code == "" {
+		code = "unknown"
+	}
+	s3Errors.WithLabelValues(operation, code).Inc()
+}
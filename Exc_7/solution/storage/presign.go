@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"ordersystem/model"
+	"ordersystem/receipt"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// presigning must not contact S3, so the region is fixed instead of being looked up
+const presignRegion = "us-east-1"
+
+// ReceiptDelivery is how receipts are handed out by the API
+type ReceiptDelivery string
+
+const (
+	// DeliverProxy streams the receipt through the ordersystem
+	DeliverProxy ReceiptDelivery = "proxy"
+	// DeliverURL returns a presigned URL as JSON
+	DeliverURL ReceiptDelivery = "url"
+	// DeliverRedirect redirects to a presigned URL
+	DeliverRedirect ReceiptDelivery = "redirect"
+)
+
+func ParseReceiptDelivery(delivery string) (ReceiptDelivery, error) {
+	switch ReceiptDelivery(delivery) {
+	case DeliverProxy, DeliverURL, DeliverRedirect:
+		return ReceiptDelivery(delivery), nil
+	}
+	return "", fmt.Errorf("unknown receipt delivery %q, use one of proxy, url, redirect", delivery)
+}
+
+// ReceiptLinker creates presigned download links of receipts
+type ReceiptLinker struct {
+	// client signs URLs for the endpoint clients can reach, which may differ from the internal one
+	client   *minio.Client
+	expiry   time.Duration
+	delivery ReceiptDelivery
+}
+
+// CreateReceiptLinker creates the linker of the receipt config. URLs are signed for the external endpoint if one is
+// configured, using the credentials of s3Cfg.
+func CreateReceiptLinker(s3 *minio.Client, s3Cfg S3Config, cfg ReceiptConfig) (*ReceiptLinker, error) {
+	linker := &ReceiptLinker{client: s3, expiry: cfg.URLExpiry, delivery: cfg.Delivery}
+	if cfg.ExternalEndpoint == "" {
+		return linker, nil
+	}
+	var err error
+	linker.client, err = minio.New(cfg.ExternalEndpoint, &minio.Options{
+		Secure: cfg.ExternalSecure,
+		Creds:  s3Cfg.credentials(),
+		Region: presignRegion,
+	})
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Presigning receipt URLs for external endpoint", slog.String("endpoint", cfg.ExternalEndpoint))
+	return linker, nil
+}
+
+// Delivery is the configured default delivery
+func (l *ReceiptLinker) Delivery() ReceiptDelivery {
+	return l.delivery
+}
+
+// PresignReceipt returns a short-lived download URL of the receipt. The receipt must exist, see EnsureReceipt.
+func (l *ReceiptLinker) PresignReceipt(ctx context.Context, order *model.Order, format receipt.Format) (*url.URL, time.Time, error) {
+	params := url.Values{}
+	params.Set("response-content-type", format.ContentType())
+	params.Set("response-content-disposition", fmt.Sprintf("attachment; filename=%s", format.Filename(order)))
+	expiresAt := time.Now().Add(l.expiry)
+	presigned, err := l.client.PresignedGetObject(ctx, OrdersBucket, format.Filename(order), l.expiry, params)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return presigned, expiresAt, nil
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"ordersystem/model"
+	"ordersystem/receipt"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+const (
+	ReceiptStatusTag = "receipt-status"
+	ReceiptVoid      = "void"
+)
+
+// ReceiptWriter serializes writing the receipts of an order. write gets the order as it is once the lock is taken,
+// see repository.DatabaseHandler.WriteReceipt.
+type ReceiptWriter interface {
+	WriteReceipt(orderID uint, write func(order *model.Order) error) error
+}
+
+// PutReceipt renders the order as markdown with the template of the renderer and stores it in the orders bucket,
+// replacing any previous receipt of the same order. Other formats rendered from the previous
+// receipt are removed, so they are rendered again on the next request.
+// Receipts of cancelled orders are tagged as void.
+func PutReceipt(ctx context.Context, s3 *minio.Client, renderer *receipt.Renderer, order *model.Order) error {
+	_, err := putReceiptFormat(ctx, s3, renderer, order, receipt.Markdown)
+	if err != nil {
+		return err
+	}
+	for _, format := range receipt.Formats {
+		if format == receipt.Markdown {
+			continue
+		}
+		start := time.Now()
+		err = s3.RemoveObject(ctx, OrdersBucket, format.Filename(order), minio.RemoveObjectOptions{})
+		observeS3("remove_object", start, err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetReceipt returns the receipt of the order in the given format. Formats other than markdown
+// are rendered on first request and stored next to the markdown receipt. They are rendered under the lock
+// of the writer from the current order, so a render cannot race PutReceipt and outlive a newer receipt.
+// The error of the writer is returned if the lock is taken.
+func GetReceipt(ctx context.Context, s3 *minio.Client, writer ReceiptWriter, renderer *receipt.Renderer, order *model.Order,
+	format receipt.Format) (io.ReadCloser, error) {
+	start := time.Now()
+	object, err := s3.GetObject(ctx, OrdersBucket, format.Filename(order), minio.GetObjectOptions{})
+	if err != nil {
+		observeS3("get_object", start, err)
+		return nil, err
+	}
+	// GetObject is lazy, errors show up on first access
+	_, err = object.Stat()
+	observeS3("get_object", start, err)
+	if err == nil {
+		return object, nil
+	}
+	_ = object.Close()
+	if format == receipt.Markdown || minio.ToErrorResponse(err).Code != "NoSuchKey" {
+		return nil, err
+	}
+	rendered, err := putLockedReceiptFormat(ctx, s3, writer, renderer, order.ID, format)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(rendered)), nil
+}
+
+// EnsureReceipt makes sure the receipt of the order exists in the given format, see GetReceipt
+func EnsureReceipt(ctx context.Context, s3 *minio.Client, writer ReceiptWriter, renderer *receipt.Renderer, order *model.Order,
+	format receipt.Format) error {
+	start := time.Now()
+	_, err := s3.StatObject(ctx, OrdersBucket, format.Filename(order), minio.StatObjectOptions{})
+	observeS3("stat_object", start, err)
+	if err == nil {
+		return nil
+	}
+	if format == receipt.Markdown || minio.ToErrorResponse(err).Code != "NoSuchKey" {
+		return err
+	}
+	_, err = putLockedReceiptFormat(ctx, s3, writer, renderer, order.ID, format)
+	return err
+}
+
+// putLockedReceiptFormat renders the order as it is under the lock of the writer
+func putLockedReceiptFormat(ctx context.Context, s3 *minio.Client, writer ReceiptWriter, renderer *receipt.Renderer,
+	orderID uint, format receipt.Format) (rendered []byte, err error) {
+	err = writer.WriteReceipt(orderID, func(order *model.Order) error {
+		rendered, err = putReceiptFormat(ctx, s3, renderer, order, format)
+		return err
+	})
+	return rendered, err
+}
+
+func putReceiptFormat(ctx context.Context, s3 *minio.Client, renderer *receipt.Renderer, order *model.Order, format receipt.Format) ([]byte, error) {
+	rendered, err := renderer.Render(order, format)
+	if err != nil {
+		return nil, err
+	}
+	opts := minio.PutObjectOptions{ContentType: format.ContentType()}
+	if order.IsCancelled() {
+		opts.UserTags = map[string]string{ReceiptStatusTag: ReceiptVoid}
+	}
+	start := time.Now()
+	_, err = s3.PutObject(ctx, OrdersBucket, format.Filename(order), bytes.NewReader(rendered), int64(len(rendered)), opts)
+	observeS3("put_object", start, err)
+	if err != nil {
+		return nil, err
+	}
+	return rendered, nil
+}
@@ -5,8 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-	"ordersystem/secrets"
-	"os"
 	"time"
 
 	"github.com/minio/minio-go/v7"
@@ -14,53 +12,56 @@ import (
 )
 
 const (
-	S3EndpointEnvKey        = "S3_ENDPOINT"
-	S3AccessKeyEnvKey       = "S3_ACCESS_KEY_ID"
-	S3SecretAccessKeyEnvKey = "S3_SECRET_ACCESS_KEY"
-	OrdersBucket            = "orders"
+	OrdersBucket       = "orders"
+	defaultWaitTimeout = 10 * time.Second
 )
 
-// CreateS3client creates an S3Client that implements all functions of the interfaces. Storage
-// interface. The S3Client requires the following env variables to be set:
-// - S3_ENDPOINT
-// - S3_ACCESS_KEY_ID or S3_ACCESS_KEY_ID_FILE
-// - S3_SECRET_ACCESS_KEY or S3_SECRET_ACCESS_KEY_FILE
-func CreateS3client() (*minio.Client, error) {
-	slog.Info("Connecting to S3")
-	s3Endpoint, exists := os.LookupEnv(S3EndpointEnvKey)
-	if !exists {
-		return nil, errors.New(fmt.Sprintf("Environment variable %s not set", S3EndpointEnvKey))
-	}
-	s3AccessKeyId, err := secrets.LoadSecretOrEnv(S3AccessKeyEnvKey)
-	if err != nil {
-		return nil, err
-	}
-	secretAccessKey, err := secrets.LoadSecretOrEnv(S3SecretAccessKeyEnvKey)
-	if err != nil {
-		return nil, err
+// S3Config holds the endpoint and credentials of S3
+type S3Config struct {
+	Endpoint        string `yaml:"endpoint" env:"S3_ENDPOINT" flag:"s3-endpoint" required:"true" usage:"host:port of S3"`
+	Secure          bool   `yaml:"secure" env:"S3_SECURE" flag:"s3-secure" usage:"use https to reach S3"`
+	AccessKeyID     string `yaml:"access_key_id" env:"S3_ACCESS_KEY_ID" secret:"true" required:"true"`
+	SecretAccessKey string `yaml:"secret_access_key" env:"S3_SECRET_ACCESS_KEY" secret:"true" required:"true"`
+	// WaitTimeout is how long to wait for S3 to come up on startup
+	WaitTimeout time.Duration `yaml:"wait_timeout" env:"S3_WAIT_TIMEOUT" flag:"s3-wait-timeout" usage:"how long to wait for S3 on startup"`
+}
+
+func DefaultS3Config() S3Config {
+	return S3Config{WaitTimeout: defaultWaitTimeout}
+}
+
+// Validate reports all invalid settings
+func (c S3Config) Validate() error {
+	if c.WaitTimeout <= 0 {
+		return errors.New("S3_WAIT_TIMEOUT must be a positive duration, i.e. 10s")
 	}
+	return nil
+}
 
-	client, err := minio.New(s3Endpoint, &minio.Options{
-		Secure: false,
-		Creds: credentials.NewStaticV4(
-			s3AccessKeyId,
-			secretAccessKey,
-			"",
-		),
+func (c S3Config) credentials() *credentials.Credentials {
+	return credentials.NewStaticV4(c.AccessKeyID, c.SecretAccessKey, "")
+}
+
+// CreateS3client creates an S3Client that implements all functions of the interfaces. Storage
+// interface. It waits up to the wait timeout of the config for S3 to become reachable and creates the orders bucket.
+func CreateS3client(cfg S3Config) (*minio.Client, error) {
+	slog.Info("Connecting to S3")
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Secure: cfg.Secure,
+		Creds:  cfg.credentials(),
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	// start healthcheck on the endpoint
+	// start healthcheck on the endpoint, it keeps running so IsOnline reflects the state for the readiness probe
 	cancelFn, err := client.HealthCheck(1 * time.Second)
 	if err != nil {
 		return nil, err
 	}
-	defer cancelFn()
-	// continually check for 10 seconds if s3 is available
+	// continually check if s3 is available
 	alive := false
-	deadline := time.Now().Add(10 * time.Second)
+	deadline := time.Now().Add(cfg.WaitTimeout)
 	for deadline.After(time.Now()) {
 		if client.IsOnline() {
 			alive = true
@@ -69,19 +70,39 @@ func CreateS3client() (*minio.Client, error) {
 		time.Sleep(1 * time.Second)
 	}
 	if !alive {
-		return nil, errors.New("S3 is not reachable, timeout after waiting for 10 seconds")
+		cancelFn()
+		return nil, fmt.Errorf("S3 is not reachable, timeout after waiting for %s", cfg.WaitTimeout)
 	}
-	// create bucket if not exists
-	exists, err = client.BucketExists(context.Background(), OrdersBucket)
+	err = ensureBucket(client)
 	if err != nil {
+		cancelFn()
 		return nil, err
 	}
-	if exists {
-		return client, nil
+	return client, nil
+}
+
+// ensureBucket creates the orders bucket if it does not exist
+func ensureBucket(client *minio.Client) error {
+	exists, err := client.BucketExists(context.Background(), OrdersBucket)
+	if err != nil || exists {
+		return err
+	}
+	return client.MakeBucket(context.Background(), OrdersBucket, minio.MakeBucketOptions{})
+}
+
+// CheckS3 checks that S3 is online and the orders bucket exists
+func CheckS3(ctx context.Context, client *minio.Client) error {
+	if !client.IsOnline() {
+		return errors.New("S3 is offline")
 	}
-	err = client.MakeBucket(context.Background(), OrdersBucket, minio.MakeBucketOptions{})
+	start := time.Now()
+	exists, err := client.BucketExists(ctx, OrdersBucket)
+	observeS3("bucket_exists", start, err)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return client, nil
+	if !exists {
+		return fmt.Errorf("bucket '%s' does not exist", OrdersBucket)
+	}
+	return nil
 }
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"ordersystem/receipt"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// CreateReceiptRenderer creates the receipt renderer of this venue. The template is loaded from the template file or
+// bucket of the config, without either the built-in template is used.
+func CreateReceiptRenderer(s3 *minio.Client, cfg ReceiptConfig) (*receipt.Renderer, error) {
+	var source receipt.TemplateSource
+	switch {
+	case cfg.TemplateFile != "":
+		source = receipt.FileSource{Path: cfg.TemplateFile}
+	case cfg.TemplateBucket != "":
+		source = &TemplateSource{s3: s3, bucket: cfg.TemplateBucket, object: cfg.TemplateObject}
+	}
+	if source != nil {
+		slog.Info("Loading receipt template", slog.String("source", source.Name()))
+	}
+	return receipt.NewRenderer(context.Background(), cfg.Venue, source)
+}
+
+// TemplateSource loads the receipt template from an S3 bucket, the ETag is used as version
+type TemplateSource struct {
+	s3     *minio.Client
+	bucket string
+	object string
+}
+
+func (s *TemplateSource) Load(ctx context.Context) (string, string, error) {
+	start := time.Now()
+	info, err := s.s3.StatObject(ctx, s.bucket, s.object, minio.StatObjectOptions{})
+	observeS3("stat_object", start, err)
+	if err != nil {
+		return "", "", err
+	}
+	start = time.Now()
+	object, err := s.s3.GetObject(ctx, s.bucket, s.object, minio.GetObjectOptions{})
+	if err != nil {
+		observeS3("get_object", start, err)
+		return "", "", err
+	}
+	defer object.Close()
+	content, err := io.ReadAll(object)
+	observeS3("get_object", start, err)
+	if err != nil {
+		return "", "", err
+	}
+	return string(content), info.ETag, nil
+}
+
+func (s *TemplateSource) Name() string {
+	return fmt.Sprintf("s3://%s/%s", s.bucket, s.object)
+}
@@ -0,0 +1,204 @@
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const tagName = "validate"
+
+// FieldError describes why the value of a single field is invalid. Field is the json path of the field.
+type FieldError struct {
+	Field   string `json:"field" example:"items[0].quantity"`
+	Rule    string `json:"rule" example:"min"`
+	Message string `json:"message" example:"must be at least 1"`
+}
+
+// Errors collects the errors of all fields of a request
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Field + " " + fieldErr.Message
+	}
+	return "invalid fields: " + strings.Join(messages, ", ")
+}
+
+// Add appends an error of the field
+func (e *Errors) Add(field string, rule string, message string) {
+	*e = append(*e, FieldError{Field: field, Rule: rule, Message: message})
+}
+
+type validator interface {
+	IsValid() bool
+}
+
+type negativeChecker interface {
+	IsNegative() bool
+}
+
+// Check validates v, a struct or pointer to a struct, against the rules in the validate tags of its fields
+// and returns the errors of all fields. Nested structs, pointers and slices are checked as well.
+// Rules are separated by commas:
+//
+//	required     the value must not be the zero value
+//	min=N        numbers must be at least N, strings and slices need at least N characters or items
+//	max=N        numbers must be at most N, strings and slices can have at most N characters or items
+//	oneof=a b    strings must be one of the space separated values
+//	valid        the value must report IsValid, i.e. model.OrderStatus or money.Money
+//	nonnegative  numbers and money must not be negative
+//
+// Rules other than required are skipped for zero values of optional (pointer) fields.
+func Check(v any) Errors {
+	var errs Errors
+	checkValue(reflect.ValueOf(v), "", &errs)
+	return errs
+}
+
+func checkValue(value reflect.Value, path string, errs *Errors) {
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !value.IsNil() {
+			checkValue(value.Elem(), path, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			checkValue(value.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case reflect.Struct:
+		checkStruct(value, path, errs)
+	}
+}
+
+func checkStruct(value reflect.Value, path string, errs *Errors) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fieldPath := joinPath(path, fieldName(field))
+		if field.Anonymous {
+			// embedded fields are flattened by encoding/json
+			fieldPath = path
+		}
+		fieldValue := value.Field(i)
+		if tag, ok := field.Tag.Lookup(tagName); ok {
+			valid := checkRules(fieldValue, fieldPath, tag, errs)
+			if !valid {
+				continue
+			}
+		}
+		checkValue(fieldValue, fieldPath, errs)
+	}
+}
+
+// checkRules reports the first broken rule of the field, so every field has at most one error
+func checkRules(value reflect.Value, path string, tag string, errs *Errors) bool {
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		if name == "required" {
+			if value.IsZero() {
+				errs.Add(path, name, "is required")
+				return false
+			}
+			continue
+		}
+		if value.Kind() == reflect.Pointer {
+			if value.IsNil() {
+				return true
+			}
+			value = value.Elem()
+		}
+		message, ok := checkRule(value, name, param)
+		if !ok {
+			errs.Add(path, name, message)
+			return false
+		}
+	}
+	return true
+}
+
+func checkRule(value reflect.Value, name string, param string) (string, bool) {
+	switch name {
+	case "min":
+		bound := parseBound(name, param)
+		if length, unit, ok := lengthOf(value); ok {
+			return fmt.Sprintf("needs at least %s %s", param, unit), length >= int(bound)
+		}
+		return fmt.Sprintf("must be at least %s", param), numberOf(value, name) >= bound
+	case "max":
+		bound := parseBound(name, param)
+		if length, unit, ok := lengthOf(value); ok {
+			return fmt.Sprintf("can have at most %s %s", param, unit), length <= int(bound)
+		}
+		return fmt.Sprintf("must be at most %s", param), numberOf(value, name) <= bound
+	case "oneof":
+		allowed := strings.Fields(param)
+		for _, option := range allowed {
+			if value.String() == option {
+				return "", true
+			}
+		}
+		return "must be one of " + strings.Join(allowed, ", "), false
+	case "valid":
+		checker, ok := value.Interface().(validator)
+		if !ok {
+			panic(fmt.Sprintf("validation: %s has no IsValid method", value.Type()))
+		}
+		return "is not valid", checker.IsValid()
+	case "nonnegative":
+		if checker, ok := value.Interface().(negativeChecker); ok {
+			return "must not be negative", !checker.IsNegative()
+		}
+		return "must not be negative", numberOf(value, name) >= 0
+	}
+	panic("validation: unknown rule " + name)
+}
+
+func lengthOf(value reflect.Value) (int, string, bool) {
+	switch value.Kind() {
+	case reflect.String:
+		return len([]rune(value.String())), "characters", true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return value.Len(), "items", true
+	}
+	return 0, "", false
+}
+
+func numberOf(value reflect.Value, rule string) float64 {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		return value.Float()
+	}
+	panic(fmt.Sprintf("validation: rule %s does not apply to %s", rule, value.Type()))
+}
+
+func parseBound(rule string, param string) float64 {
+	bound, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		panic(fmt.Sprintf("validation: rule %s needs a number, got '%s'", rule, param))
+	}
+	return bound
+}
+
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,135 @@
+package validation
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+type status string
+
+func (s status) IsValid() bool {
+	return s == "open" || s == "closed"
+}
+
+type amount int
+
+func (a amount) IsNegative() bool {
+	return a < 0
+}
+
+type item struct {
+	Quantity uint64 `json:"quantity" validate:"min=1,max=10"`
+}
+
+type Embedded struct {
+	Note string `json:"note" validate:"max=5"`
+}
+
+type request struct {
+	Embedded
+	Name     string   `json:"name" validate:"required,max=5"`
+	Kind     string   `json:"kind,omitempty" validate:"oneof=a b"`
+	Status   status   `json:"status" validate:"valid"`
+	Price    amount   `json:"price" validate:"nonnegative"`
+	Ratio    float64  `json:"ratio" validate:"nonnegative,max=1.5"`
+	Limit    *int     `json:"limit,omitempty" validate:"min=1"`
+	Owner    *string  `json:"owner" validate:"required"`
+	Items    []item   `json:"items" validate:"min=1,max=2"`
+	Nested   *item    `json:"nested,omitempty"`
+	Tags     []string `validate:"max=1"`
+	internal string   `validate:"required"`
+}
+
+func validRequest() request {
+	owner := "anna"
+	return request{
+		Name:   "Beer",
+		Kind:   "a",
+		Status: "open",
+		Owner:  &owner,
+		Items:  []item{{Quantity: 1}},
+	}
+}
+
+func TestCheck(t *testing.T) {
+	zero, eleven := 0, 11
+	tests := []struct {
+		name   string
+		modify func(r *request)
+		want   []string
+	}{
+		{"valid", func(r *request) {}, nil},
+		{"required string", func(r *request) { r.Name = "" }, []string{"name required"}},
+		{"required pointer", func(r *request) { r.Owner = nil }, []string{"owner required"}},
+		{"max characters count runes", func(r *request) { r.Name = "Bière" }, nil},
+		{"max characters", func(r *request) { r.Name = "Spritzer" }, []string{"name max"}},
+		{"first broken rule only", func(r *request) { r.Ratio = -2 }, []string{"ratio nonnegative"}},
+		{"max float", func(r *request) { r.Ratio = 1.6 }, []string{"ratio max"}},
+		{"oneof", func(r *request) { r.Kind = "c" }, []string{"kind oneof"}},
+		{"valid method", func(r *request) { r.Status = "lost" }, []string{"status valid"}},
+		{"nonnegative method", func(r *request) { r.Price = -1 }, []string{"price nonnegative"}},
+		{"nil optional pointer", func(r *request) { r.Limit = nil }, nil},
+		{"optional pointer", func(r *request) { r.Limit = &eleven }, nil},
+		{"optional pointer below min", func(r *request) { r.Limit = &zero }, []string{"limit min"}},
+		{"too few items", func(r *request) { r.Items = nil }, []string{"items min"}},
+		{"too many items", func(r *request) { r.Items = make([]item, 3) }, []string{"items max"}},
+		{"rules of slice elements", func(r *request) { r.Items = []item{{Quantity: 1}, {Quantity: 11}} },
+			[]string{"items[1].quantity max"}},
+		{"rules of nested struct", func(r *request) { r.Nested = &item{} }, []string{"nested.quantity min"}},
+		{"embedded fields are flattened", func(r *request) { r.Note = "too long" }, []string{"note max"}},
+		{"field without json name", func(r *request) { r.Tags = []string{"a", "b"} }, []string{"Tags max"}},
+		{"unexported fields are skipped", func(r *request) { r.internal = "" }, nil},
+		{"all fields are reported", func(r *request) { r.Name = ""; r.Status = "lost"; r.Items = []item{{}} },
+			[]string{"name required", "status valid", "items[0].quantity min"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := validRequest()
+			tt.modify(&r)
+			var got []string
+			for _, fieldErr := range Check(&r) {
+				got = append(got, fieldErr.Field+" "+fieldErr.Rule)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPanicsOnBadTag(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		panic string
+	}{
+		{"unknown rule", &struct {
+			Name string `validate:"email"`
+		}{}, "unknown rule email"},
+		{"bound that is not a number", &struct {
+			Name string `validate:"max=ten"`
+		}{}, "rule max needs a number"},
+		{"valid without IsValid", &struct {
+			Name string `validate:"valid"`
+		}{}, "has no IsValid method"},
+		{"min on a bool", &struct {
+			Active bool `validate:"min=1"`
+		}{}, "rule min does not apply to bool"},
+		{"nonnegative on a string", &struct {
+			Name string `validate:"nonnegative"`
+		}{}, "rule nonnegative does not apply to string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				recovered := recover()
+				message, _ := recovered.(string)
+				if !strings.Contains(message, tt.panic) {
+					t.Errorf("Check() panicked with %v, want a panic containing %q", recovered, tt.panic)
+				}
+			}()
+			Check(tt.value)
+		})
+	}
+}